@@ -0,0 +1,274 @@
+// Command jobsyctl runs maintenance tasks against the jobsy database.
+//
+//	jobsyctl import -company <id> -file jobs.csv [-format csv|json] [-dry-run] [-batch 100]
+//	jobsyctl purge [-retention-days 30]
+//	jobsyctl set-role -email <email> -role applicant|company|admin
+//	jobsyctl backfill-slugs
+//	jobsyctl backfill-locations
+//	jobsyctl backfill-descriptions
+//	jobsyctl reconcile-counts [-job <id>]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"jobsy-api/config"
+	"jobsy-api/models"
+	"jobsy-api/services"
+	"jobsy-api/storage"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	_ = godotenv.Load()
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		log.Fatalln("mongodb uri string not found : ")
+	}
+
+	command, args := os.Args[1], os.Args[2:]
+	switch command {
+	case "import":
+		client := connect(uri)
+		defer disconnect(client)
+		runImport(client, args)
+	case "purge":
+		client := connect(uri)
+		defer disconnect(client)
+		runPurge(client, args)
+	case "set-role":
+		client := connect(uri)
+		defer disconnect(client)
+		runSetRole(client, args)
+	case "backfill-slugs":
+		client := connect(uri)
+		defer disconnect(client)
+		runBackfillSlugs(client)
+	case "backfill-locations":
+		client := connect(uri)
+		defer disconnect(client)
+		runBackfillLocations(client)
+	case "backfill-descriptions":
+		client := connect(uri)
+		defer disconnect(client)
+		runBackfillDescriptions(client)
+	case "reconcile-counts":
+		client := connect(uri)
+		defer disconnect(client)
+		if err := services.CheckTransactions(client); err != nil {
+			log.Fatal(err)
+		}
+		runReconcileCounts(client, args)
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: jobsyctl <command> [flags]")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  import   bulk import jobs from a CSV or JSON file")
+	fmt.Fprintln(os.Stderr, "  purge    permanently remove jobs deleted longer ago than the retention window")
+	fmt.Fprintln(os.Stderr, "  set-role change the role of a user, e.g. to appoint an admin")
+	fmt.Fprintln(os.Stderr, "  backfill-slugs  give jobs created before slugs existed a slug")
+	fmt.Fprintln(os.Stderr, "  backfill-locations  give jobs saved with a single location a location list")
+	fmt.Fprintln(os.Stderr, "  backfill-descriptions  render the Markdown description of jobs saved before descriptions were rendered")
+	fmt.Fprintln(os.Stderr, "  reconcile-counts  recompute the applicant counters of jobs from their applications")
+	os.Exit(2)
+}
+
+func connect(uri string) *mongo.Client {
+	client, err := config.ConnectDB(uri)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+func disconnect(client *mongo.Client) {
+	if err := client.Disconnect(context.TODO()); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runImport(client *mongo.Client, args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	companyHex := flags.String("company", "", "ID of the company (user) that owns the imported jobs")
+	file := flags.String("file", "", "path to the CSV or JSON file")
+	format := flags.String("format", "", "csv or json (defaults to the file extension)")
+	dryRun := flags.Bool("dry-run", false, "validate rows without inserting anything")
+	batchSize := flags.Int("batch", services.DefaultImportBatchSize, "number of jobs inserted per batch")
+	flags.Parse(args)
+
+	companyID, err := primitive.ObjectIDFromHex(*companyHex)
+	if err != nil || *file == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*file)), ".")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	var rows []services.ImportRow
+	switch *format {
+	case "csv":
+		rows, err = services.ParseJobsCSV(f)
+	case "json":
+		rows, err = services.ParseJobsJSON(f)
+	default:
+		log.Fatalf("unsupported import format %q, use csv or json", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jobService := services.NewJobService(client, "jobs", "applicants", "companies")
+	report, err := jobService.ImportJobs(companyID, rows, services.ImportOptions{DryRun: *dryRun, BatchSize: *batchSize})
+	printJSON(report)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runPurge(client *mongo.Client, args []string) {
+	flags := flag.NewFlagSet("purge", flag.ExitOnError)
+	retentionDays := flags.Int("retention-days", 0, "days deleted jobs are kept (defaults to JOB_RETENTION_DAYS or 30)")
+	flags.Parse(args)
+
+	jobService := services.NewJobService(client, "jobs", "applicants", "companies")
+	retention := config.JobRetention()
+	if *retentionDays > 0 {
+		retention = time.Duration(*retentionDays) * 24 * time.Hour
+	}
+	jobService.SetRetention(retention)
+	jobService.OnPurge(services.NewSavedJobService(client, "saved_jobs", "jobs").DeleteForJobs)
+	jobService.OnPurge(services.NewAnalyticsService(client, "job_events").DeleteForJobs)
+	applicantService := services.NewApplicantService(client, "applicants", "jobs", "users", "application_events", services.NewLogNotifier())
+	applicantService.SetResumeStorage(openStorage(), config.ResumeMaxBytes(), config.ResumeURLTTL())
+	jobService.OnPurge(applicantService.DeleteResumesForJobs)
+	jobService.OnPurge(applicantService.DeleteEventsForJobs)
+
+	report, err := jobService.PurgeDeletedJobs()
+	printJSON(report)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// openStorage sets up the file storage the way the API server does, so that
+// purging removes the resumes it stored.
+func openStorage() storage.Storage {
+	var store storage.Storage
+	var err error
+	switch config.StorageBackend() {
+	case "s3":
+		store, err = storage.NewS3(storage.S3Options(config.S3()))
+	case "local":
+		signingKey := config.StorageSigningKey()
+		if signingKey == "" {
+			signingKey = os.Getenv("JWT_SECRET")
+		}
+		store, err = storage.NewLocal(config.StorageDir(), config.PublicBaseURL()+"/api/files", signingKey)
+	default:
+		log.Fatalf("unknown storage backend %q", config.StorageBackend())
+	}
+	if err != nil {
+		log.Fatal("failed to set up file storage: ", err)
+	}
+	return store
+}
+
+func runSetRole(client *mongo.Client, args []string) {
+	flags := flag.NewFlagSet("set-role", flag.ExitOnError)
+	email := flags.String("email", "", "email of the user")
+	role := flags.String("role", "", "applicant, company or admin")
+	flags.Parse(args)
+
+	switch *role {
+	case models.RoleApplicant, models.RoleCompany, models.RoleAdmin:
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *email == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	if err := services.NewAuthService(client, "users").SetUserRole(*email, *role); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s is now %s\n", *email, *role)
+}
+
+func runBackfillSlugs(client *mongo.Client) {
+	updated, err := services.NewJobService(client, "jobs", "applicants", "companies").BackfillSlugs()
+	fmt.Printf("added slugs to %d jobs\n", updated)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runBackfillLocations(client *mongo.Client) {
+	updated, err := services.NewJobService(client, "jobs", "applicants", "companies").BackfillLocations()
+	fmt.Printf("added location lists to %d jobs\n", updated)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runBackfillDescriptions(client *mongo.Client) {
+	updated, err := services.NewJobService(client, "jobs", "applicants", "companies").BackfillDescriptions()
+	fmt.Printf("rendered descriptions of %d jobs\n", updated)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runReconcileCounts(client *mongo.Client, args []string) {
+	flags := flag.NewFlagSet("reconcile-counts", flag.ExitOnError)
+	job := flags.String("job", "", "only reconcile this job")
+	flags.Parse(args)
+
+	var jobIDs []primitive.ObjectID
+	if *job != "" {
+		jobID, err := primitive.ObjectIDFromHex(*job)
+		if err != nil {
+			log.Fatal("invalid job ID")
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+	corrected, err := services.NewApplicantService(client, "applicants", "jobs", "users", "application_events", services.NewLogNotifier()).ReconcileCounts(jobIDs...)
+	fmt.Printf("corrected applicant counts of %d jobs\n", corrected)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PublicBaseURL is the externally reachable address of the API, used when building
+// absolute links for feeds and other syndicated content.
+func PublicBaseURL() string {
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// DefaultLanguage is the language of jobs saved without one, from DEFAULT_LANGUAGE
+// (default "en").
+func DefaultLanguage() string {
+	if lang := strings.TrimSpace(os.Getenv("DEFAULT_LANGUAGE")); lang != "" {
+		return strings.ToLower(lang)
+	}
+	return "en"
+}
+
+// JobRetention is how long deleted jobs can be restored before they are purged for
+// good, from JOB_RETENTION_DAYS (default 30).
+func JobRetention() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("JOB_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ModerationKeywords are extra phrases that hold a job for review, from the comma
+// separated MODERATION_KEYWORDS.
+func ModerationKeywords() []string {
+	return splitList(os.Getenv("MODERATION_KEYWORDS"))
+}
+
+// ModerationPaymentTerms are extra phrases that count as asking candidates for
+// money, from the comma separated MODERATION_PAYMENT_TERMS.
+func ModerationPaymentTerms() []string {
+	return splitList(os.Getenv("MODERATION_PAYMENT_TERMS"))
+}
+
+// ModerationDuplicateSimilarity is how similar (0 to 1) a job's text must be to
+// another company's job to be held as a duplicate, from
+// MODERATION_DUPLICATE_SIMILARITY (default 0.9). 0 turns the check off.
+func ModerationDuplicateSimilarity() float64 {
+	value := os.Getenv("MODERATION_DUPLICATE_SIMILARITY")
+	if value == "" {
+		return 0.9
+	}
+	similarity, err := strconv.ParseFloat(value, 64)
+	if err != nil || similarity < 0 || similarity > 1 {
+		return 0.9
+	}
+	return similarity
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StorageBackend is where uploaded files are kept, "local" or "s3", from
+// STORAGE_BACKEND (default "local").
+func StorageBackend() string {
+	if backend := strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_BACKEND"))); backend != "" {
+		return backend
+	}
+	return "local"
+}
+
+// StorageDir is the directory of the local backend, from STORAGE_DIR (default
+// "uploads").
+func StorageDir() string {
+	if dir := os.Getenv("STORAGE_DIR"); dir != "" {
+		return dir
+	}
+	return "uploads"
+}
+
+// StorageSigningKey signs the download links of the local backend, from
+// STORAGE_SIGNING_KEY. When unset the JWT secret is used.
+func StorageSigningKey() string {
+	return os.Getenv("STORAGE_SIGNING_KEY")
+}
+
+// S3Settings configure the s3 backend, e.g. a MinIO server at
+// S3_ENDPOINT=http://localhost:9000 with S3_PATH_STYLE=true.
+type S3Settings struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+}
+
+// S3 reads S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY and
+// S3_PATH_STYLE.
+func S3() S3Settings {
+	pathStyle, _ := strconv.ParseBool(os.Getenv("S3_PATH_STYLE"))
+	return S3Settings{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    os.Getenv("S3_REGION"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PathStyle: pathStyle,
+	}
+}
+
+// ResumeMaxBytes is the largest resume upload accepted, from RESUME_MAX_MB
+// (default 5).
+func ResumeMaxBytes() int64 {
+	megabytes, err := strconv.Atoi(os.Getenv("RESUME_MAX_MB"))
+	if err != nil || megabytes <= 0 {
+		megabytes = 5
+	}
+	return int64(megabytes) << 20
+}
+
+// ResumeURLTTL is how long a resume download link works, from
+// RESUME_URL_TTL_MINUTES (default 15).
+func ResumeURLTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("RESUME_URL_TTL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
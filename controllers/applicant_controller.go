@@ -1,9 +1,13 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"jobsy-api/models"
 	"jobsy-api/services"
 	"jobsy-api/utils"
+	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -12,10 +16,11 @@ import (
 
 type ApplicantController struct {
 	applicantService *services.ApplicantService
+	analyticsService *services.AnalyticsService
 }
 
-func NewApplicantController(applicantService *services.ApplicantService) *ApplicantController {
-	return &ApplicantController{applicantService: applicantService}
+func NewApplicantController(applicantService *services.ApplicantService, analyticsService *services.AnalyticsService) *ApplicantController {
+	return &ApplicantController{applicantService: applicantService, analyticsService: analyticsService}
 }
 
 func (ac *ApplicantController) CreateApplicant(c *gin.Context) {
@@ -24,25 +29,125 @@ func (ac *ApplicantController) CreateApplicant(c *gin.Context) {
 		return
 	}
 
-	jobID := c.Param("id")
-
 	var applicant models.Applicant
-	if err := c.ShouldBindJSON(&applicant); err != nil {
+	resume, ok := ac.bindApplication(c, &applicant)
+	if !ok {
+		return
+	}
+	if resume != nil {
+		defer resume.Content.(io.Closer).Close()
+	}
+	if jobID := c.Param("id"); jobID != "" {
+		applicant.JobID, _ = primitive.ObjectIDFromHex(jobID)
+	}
+	err := ac.applicantService.CreateApplicant(&applicant, userId, resume)
+	var duplicate *services.DuplicateApplicationError
+	if errors.As(err, &duplicate) {
+		c.JSON(http.StatusConflict, utils.Response{
+			Status:  utils.Error,
+			Message: "You have already applied to this job; update your application instead",
+			Data:    gin.H{"applicationId": duplicate.ApplicationID},
+		})
+		return
+	}
+	if errors.Is(err, services.ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidApplication) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	applicant.JobID, _ = primitive.ObjectIDFromHex(jobID)
-	err := ac.applicantService.CreateApplicant(&applicant, userId)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := ac.analyticsService.RecordEvent(applicant.JobID, models.ApplyCompleted, utils.VisitorID(c)); err != nil {
+		log.Printf("failed to record %s event for job %s: %v", models.ApplyCompleted, applicant.JobID.Hex(), err)
+	}
 	c.JSON(http.StatusOK, utils.Response{
 		Status:  "success",
 		Message: "Application successfully submitted",
 	})
 }
 
+// bindApplication reads an application from a JSON body, or from a multipart form
+// with the application as JSON in the "application" field next to the resume
+// file in "resume". The resume, when sent, must be closed by the caller.
+func (ac *ApplicantController) bindApplication(c *gin.Context, applicant *models.Applicant) (*services.ResumeUpload, bool) {
+	if c.ContentType() != "multipart/form-data" {
+		if err := c.ShouldBindJSON(applicant); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return nil, false
+		}
+		return nil, true
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ac.applicantService.MaxResumeBytes()+1<<20)
+	if err := c.Request.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "the resume is too large"})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err := json.Unmarshal([]byte(c.PostForm("application")), applicant); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid application: " + err.Error()})
+		return nil, false
+	}
+	header, err := c.FormFile("resume")
+	if errors.Is(err, http.ErrMissingFile) {
+		return nil, true
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &services.ResumeUpload{Filename: header.Filename, Size: header.Size, Content: file}, true
+}
+
+// UpdateApplication lets candidates change their own pending application instead
+// of applying again. Only the fields sent are changed.
+func (c *ApplicantController) UpdateApplication(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid application ID"})
+		return
+	}
+
+	var changes models.Applicant
+	resume, ok := c.bindApplication(ctx, &changes)
+	if !ok {
+		return
+	}
+	if resume != nil {
+		defer resume.Content.(io.Closer).Close()
+	}
+
+	applicant, err := c.applicantService.UpdateApplication(id, userID, &changes, resume)
+	if err != nil {
+		respondApplicantError(ctx, err, "Failed to update the application")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Application updated successfully",
+		Data:    applicant,
+	})
+}
+
 func (c *ApplicantController) GetApplicantsByJobID(ctx *gin.Context) {
 	jobID := ctx.Param("id")
 
@@ -63,14 +168,67 @@ func (c *ApplicantController) GetApplicantsByJobID(ctx *gin.Context) {
 	})
 }
 
+func (c *ApplicantController) GetLocationBreakdown(ctx *gin.Context) {
+	jobID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+
+	breakdown, err := c.applicantService.GetLocationBreakdown(jobID)
+	if errors.Is(err, services.ErrJobNotFound) {
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve the location breakdown"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Location breakdown retrieved successfully",
+		Data:    breakdown,
+	})
+}
+
+// GetPipelineBoard returns the job's applications grouped by pipeline stage.
+func (c *ApplicantController) GetPipelineBoard(ctx *gin.Context) {
+	jobID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+
+	board, err := c.applicantService.GetPipelineBoard(jobID)
+	if err != nil {
+		respondApplicantError(ctx, err, "Failed to retrieve the pipeline")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Pipeline retrieved successfully",
+		Data:    board,
+	})
+}
+
+// GetApplicantByID shows an application to its candidate, the company that owns
+// the job, or an admin.
 func (c *ApplicantController) GetApplicantByID(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 
-	applicant, err := c.applicantService.GetApplicantByID(id)
+	viewer := userID
+	if ctx.GetString("role") == models.RoleAdmin {
+		viewer = primitive.NilObjectID
+	}
+	applicant, err := c.applicantService.GetApplicantByID(id, viewer)
 	if err != nil {
-		ctx.JSON(http.StatusNotFound, utils.Response{
-			Status: utils.Error,
-		})
+		respondApplicantError(ctx, err, "Failed to retrieve the application")
 		return
 	}
 
@@ -80,12 +238,20 @@ func (c *ApplicantController) GetApplicantByID(ctx *gin.Context) {
 	})
 }
 
+// UpdateApplicantStatus moves an application to another stage of the job's
+// pipeline, for the company that owns the job or an admin. The optional note is
+// kept on the application's timeline.
 func (c *ApplicantController) UpdateApplicantStatus(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
 	id := ctx.Param("id")
 
-	// Define a structure to bind only the status field
+	// Define a structure to bind only the status field and its note
 	var statusUpdate struct {
 		Status models.ApplicationStatus `json:"status"`
+		Note   string                   `json:"note"`
 	}
 
 	if err := ctx.ShouldBindJSON(&statusUpdate); err != nil {
@@ -94,9 +260,9 @@ func (c *ApplicantController) UpdateApplicantStatus(ctx *gin.Context) {
 	}
 
 	// Call the service to update the status
-	updatedApplicant, err := c.applicantService.UpdateApplicantStatus(id, statusUpdate.Status)
+	updatedApplicant, err := c.applicantService.UpdateApplicantStatus(id, statusUpdate.Status, statusUpdate.Note, userID, ctx.GetString("role"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, utils.Response{Message: err.Error(), Status: utils.Success})
+		respondApplicantError(ctx, err, "Failed to update the application status")
 		return
 	}
 
@@ -105,3 +271,207 @@ func (c *ApplicantController) UpdateApplicantStatus(ctx *gin.Context) {
 		Data:   updatedApplicant,
 	})
 }
+
+// GetTimeline returns the status history of an application. The company that owns
+// the job and admins see every change with who made it and the notes; the
+// candidate sees their own application's progress without them.
+func (c *ApplicantController) GetTimeline(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid application ID"})
+		return
+	}
+
+	var timeline interface{}
+	switch ctx.GetString("role") {
+	case models.RoleAdmin:
+		timeline, err = c.applicantService.GetTimeline(id, primitive.NilObjectID)
+	case models.RoleCompany:
+		timeline, err = c.applicantService.GetTimeline(id, userID)
+	default:
+		timeline, err = c.applicantService.GetCandidateTimeline(id, userID)
+	}
+	if err != nil {
+		respondApplicantError(ctx, err, "Failed to retrieve the timeline")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Timeline retrieved successfully",
+		Data:    timeline,
+	})
+}
+
+// DeleteApplicant lets candidates delete their own applications and admins any.
+func (c *ApplicantController) DeleteApplicant(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid application ID"})
+		return
+	}
+
+	owner := userID
+	if ctx.GetString("role") == models.RoleAdmin {
+		owner = primitive.NilObjectID
+	}
+	if err := c.applicantService.DeleteApplicant(id, owner); err != nil {
+		respondApplicantError(ctx, err, "Failed to delete the application")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{Status: utils.Success, Message: "Application deleted successfully"})
+}
+
+// WithdrawApplication lets candidates withdraw their own application, with an
+// optional reason passed on to the recruiter.
+func (c *ApplicantController) WithdrawApplication(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid application ID"})
+		return
+	}
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid withdrawal payload"})
+			return
+		}
+	}
+
+	applicant, err := c.applicantService.WithdrawApplication(id, userID, request.Reason)
+	if err != nil {
+		respondApplicantError(ctx, err, "Failed to withdraw the application")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Application withdrawn successfully",
+		Data:    applicant,
+	})
+}
+
+// ReconcileCounts recomputes applicant counters from the stored applications, for
+// the job given by ?jobId or for every job.
+func (c *ApplicantController) ReconcileCounts(ctx *gin.Context) {
+	var jobIDs []primitive.ObjectID
+	if value := ctx.Query("jobId"); value != "" {
+		jobID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+			return
+		}
+		jobIDs = append(jobIDs, jobID)
+	}
+
+	corrected, err := c.applicantService.ReconcileCounts(jobIDs...)
+	if err != nil {
+		respondApplicantError(ctx, err, "Failed to reconcile applicant counts")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Applicant counts reconciled successfully",
+		Data:    gin.H{"corrected": corrected},
+	})
+}
+
+// ParseResume reads the resume sent in the "resume" form field and returns the
+// details found in it, for prefilling the application form.
+func (c *ApplicantController) ParseResume(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, c.applicantService.MaxResumeBytes()+1<<20)
+	header, err := ctx.FormFile("resume")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ctx.JSON(http.StatusRequestEntityTooLarge, utils.Response{Status: utils.Error, Message: "The resume is too large"})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "A resume file is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to read the resume"})
+		return
+	}
+	defer file.Close()
+
+	profile, err := c.applicantService.ParseResume(&services.ResumeUpload{Filename: header.Filename, Size: header.Size, Content: file})
+	if err != nil {
+		respondApplicantError(ctx, err, "Failed to read the resume")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Resume read successfully",
+		Data:    profile,
+	})
+}
+
+// GetResumeLink returns a short-lived download link for the resume uploaded with
+// an application, for the company that owns the job.
+func (c *ApplicantController) GetResumeLink(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid application ID"})
+		return
+	}
+
+	link, err := c.applicantService.GetResumeLink(id, userID)
+	if err != nil {
+		respondApplicantError(ctx, err, "Failed to create the resume link")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{Status: utils.Success, Data: link})
+}
+
+func respondApplicantError(ctx *gin.Context, err error, message string) {
+	var duplicate *services.DuplicateApplicationError
+	switch {
+	case errors.As(err, &duplicate):
+		ctx.JSON(http.StatusConflict, utils.Response{
+			Status:  utils.Error,
+			Message: "The candidate already has an active application to this job",
+			Data:    gin.H{"applicationId": duplicate.ApplicationID},
+		})
+	case errors.Is(err, services.ErrDuplicateApplication):
+		ctx.JSON(http.StatusConflict, utils.Response{Status: utils.Error, Message: "The candidate already has an active application to this job"})
+	case errors.Is(err, services.ErrApplicantNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Application not found"})
+	case errors.Is(err, services.ErrJobNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job not found"})
+	case errors.Is(err, services.ErrNoResumeFile):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "This application has no uploaded resume"})
+	case errors.Is(err, services.ErrNotJobOwner):
+		ctx.JSON(http.StatusForbidden, utils.Response{Status: utils.Error, Message: "You are not authorized to perform this action"})
+	case errors.Is(err, services.ErrUnreadableResume):
+		ctx.JSON(http.StatusUnprocessableEntity, utils.Response{Status: utils.Error, Message: "No text could be read from the resume; it may be scanned or encrypted"})
+	case errors.Is(err, services.ErrInvalidApplication):
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: message + ": " + err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: message})
+	}
+}
@@ -27,6 +27,11 @@ func (ac *AuthController) Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: err.Error()})
 		return
 	}
+	// Admins are appointed with jobsyctl, never self-registered.
+	if user.Role != models.RoleApplicant && user.Role != models.RoleCompany {
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Role must be applicant or company"})
+		return
+	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
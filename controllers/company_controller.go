@@ -18,6 +18,7 @@
 package controllers
 
 import (
+	"errors"
 	"jobsy-api/models"
 	"jobsy-api/services"
 	"jobsy-api/utils"
@@ -74,3 +75,38 @@ func (cc *CompanyController) GetCompanyByID(c *gin.Context) {
 		Data:   company,
 	})
 }
+
+// SetDefaultPipeline sets the hiring pipeline the company's new jobs start with.
+func (cc *CompanyController) SetDefaultPipeline(c *gin.Context) {
+	userID, ok := utils.ExtractUserID(c)
+	if !ok {
+		return
+	}
+	var request struct {
+		Stages []models.PipelineStage `json:"stages"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid pipeline payload"})
+		return
+	}
+
+	company, err := cc.companyService.SetDefaultPipeline(userID, request.Stages)
+	if errors.Is(err, services.ErrInvalidPipeline) {
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrCompanyNotFound) {
+		c.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Company not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to update the pipeline"})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Pipeline updated successfully",
+		Data:    company.Pipeline,
+	})
+}
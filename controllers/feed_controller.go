@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"jobsy-api/config"
+	"jobsy-api/services"
+	"jobsy-api/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type FeedController struct {
+	syndicationService *services.SyndicationService
+}
+
+func NewFeedController(syndicationService *services.SyndicationService) *FeedController {
+	return &FeedController{syndicationService: syndicationService}
+}
+
+func (fc *FeedController) GetRSSFeed(c *gin.Context) {
+	fc.renderFeed(c, "application/rss+xml; charset=utf-8", services.RenderRSS)
+}
+
+func (fc *FeedController) GetAtomFeed(c *gin.Context) {
+	fc.renderFeed(c, "application/atom+xml; charset=utf-8", services.RenderAtom)
+}
+
+func (fc *FeedController) GetXMLFeed(c *gin.Context) {
+	fc.renderFeed(c, "application/xml; charset=utf-8", services.RenderXMLFeed)
+}
+
+// renderFeed loads the Open jobs for the feed, optionally filtered with ?company=<id>
+// and capped with ?limit=, and writes them using the given renderer.
+func (fc *FeedController) renderFeed(c *gin.Context, contentType string, render func(services.FeedInfo, []services.FeedEntry) ([]byte, error)) {
+	var companyID *primitive.ObjectID
+	if company := c.Query("company"); company != "" {
+		id, err := primitive.ObjectIDFromHex(company)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid company ID"})
+			return
+		}
+		companyID = &id
+	}
+	limit, _ := strconv.ParseInt(c.Query("limit"), 10, 64)
+
+	entries, err := fc.syndicationService.GetFeedEntries(companyID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to build job feed"})
+		return
+	}
+
+	info := services.FeedInfo{
+		Title:       "Jobsy open positions",
+		Description: "Latest open jobs posted on Jobsy",
+		BaseURL:     config.PublicBaseURL(),
+	}
+	if companyID != nil && len(entries) > 0 && entries[0].Company != nil {
+		info.Title = entries[0].Company.Name + " open positions on Jobsy"
+		info.Description = "Latest open jobs posted by " + entries[0].Company.Name
+	}
+
+	body, err := render(info, entries)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to render job feed"})
+		return
+	}
+	c.Data(http.StatusOK, contentType, body)
+}
+
+func (fc *FeedController) GetJobPosting(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+
+	entry, err := fc.syndicationService.GetFeedEntry(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job not found"})
+		return
+	}
+
+	c.Header("Content-Type", "application/ld+json; charset=utf-8")
+	c.JSON(http.StatusOK, services.BuildJobPosting(config.PublicBaseURL(), *entry))
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"errors"
+	"jobsy-api/storage"
+	"jobsy-api/utils"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileController struct {
+	store storage.Storage
+}
+
+func NewFileController(store storage.Storage) *FileController {
+	return &FileController{store: store}
+}
+
+// ServeFile downloads a file through a signed link of the local storage backend.
+// Other backends hand out links to their own servers.
+func (fc *FileController) ServeFile(ctx *gin.Context) {
+	local, ok := fc.store.(*storage.Local)
+	if !ok {
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "File not found"})
+		return
+	}
+	key := strings.TrimPrefix(ctx.Param("key"), "/")
+	if err := local.Verify(key, ctx.Request.URL.Query()); err != nil {
+		ctx.JSON(http.StatusForbidden, utils.Response{Status: utils.Error, Message: "This download link is invalid or has expired"})
+		return
+	}
+
+	file, err := local.Open(ctx.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "File not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to read the file"})
+		return
+	}
+	defer file.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	ctx.DataFromReader(http.StatusOK, -1, contentType, file, map[string]string{
+		"Content-Disposition":    `attachment; filename="` + path.Base(key) + `"`,
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, no-store",
+	})
+}
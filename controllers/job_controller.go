@@ -1,21 +1,28 @@
 package controllers
 
 import (
+	"errors"
+	"io"
 	"jobsy-api/models"
 	"jobsy-api/services"
 	"jobsy-api/utils"
+	"log"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type JobController struct {
-	jobService *services.JobService
+	jobService       *services.JobService
+	analyticsService *services.AnalyticsService
 }
 
-func NewJobController(jobService *services.JobService) *JobController {
-	return &JobController{jobService: jobService}
+func NewJobController(jobService *services.JobService, analyticsService *services.AnalyticsService) *JobController {
+	return &JobController{jobService: jobService, analyticsService: analyticsService}
 }
 
 func (jc *JobController) CreateJob(c *gin.Context) {
@@ -36,6 +43,14 @@ func (jc *JobController) CreateJob(c *gin.Context) {
 
 	// Create the job using the job service
 	result, err := jc.jobService.CreateJob(&job)
+	if errors.Is(err, services.ErrInvalidJob) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		c.JSON(http.StatusPaymentRequired, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -45,22 +60,186 @@ func (jc *JobController) CreateJob(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+const maxImportSize = 10 << 20
+
+// ImportJobs accepts a CSV or JSON array of jobs either as a multipart "file" field
+// or as the raw request body. The format comes from ?format=, the file extension or
+// the content type, in that order.
+func (jc *JobController) ImportJobs(c *gin.Context) {
+	userID, ok := utils.ExtractUserID(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	format := strings.ToLower(c.Query("format"))
+
+	var body io.Reader = c.Request.Body
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	}
+	if format == "" {
+		if strings.Contains(c.ContentType(), "csv") {
+			format = "csv"
+		} else {
+			format = "json"
+		}
+	}
+
+	var rows []services.ImportRow
+	var err error
+	switch format {
+	case "csv":
+		rows, err = services.ParseJobsCSV(body)
+	case "json":
+		rows, err = services.ParseJobsJSON(body)
+	default:
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Unsupported import format, use csv or json"})
+		return
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, utils.Response{Status: utils.Error, Message: "The import file is too large"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: err.Error()})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.Query("dryRun"))
+	batchSize, _ := strconv.Atoi(c.Query("batchSize"))
+	report, err := jc.jobService.ImportJobs(userID, rows, services.ImportOptions{DryRun: dryRun, BatchSize: batchSize})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to import jobs", Data: report})
+		return
+	}
+
+	message := "Jobs imported successfully"
+	if dryRun {
+		message = "Dry run completed, no jobs were imported"
+	}
+	c.JSON(http.StatusOK, utils.Response{Status: utils.Success, Message: message, Data: report})
+}
+
+// GetJobByID accepts either the job ID or its slug. Old slugs redirect to the
+// canonical URL. Jobs that are not public are only shown to their owner and admins.
 func (jc *JobController) GetJobByID(c *gin.Context) {
+	viewerID, role := viewer(c)
+	job, moved, err := jc.jobService.GetVisibleJobByRef(c.Param("id"), viewerID, role)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return
+	}
+	if moved {
+		c.Redirect(http.StatusMovedPermanently, job.CanonicalURL)
+		return
+	}
+	if job.Status == models.Open {
+		jc.recordEvent(c, job.ID, models.JobViewed)
+	}
+	localize(c, job)
+	c.Header("Content-Language", job.ContentLanguage)
+	c.JSON(http.StatusOK, job)
+}
+
+// viewer is the caller of a public route, with a zero ID when anonymous.
+func viewer(c *gin.Context) (primitive.ObjectID, string) {
+	value, _ := c.Get("userId")
+	userID, _ := value.(primitive.ObjectID)
+	return userID, c.GetString("role")
+}
+
+// localize serves the job text in the language the client prefers, see
+// utils.PreferredLanguages.
+func localize(c *gin.Context, jobs ...*models.Job) {
+	c.Header("Vary", "Accept-Language")
+	services.LocalizeJobs(utils.PreferredLanguages(c), jobs...)
+}
+
+// RecordApplyStart is called by the client when a visitor opens the application form.
+func (jc *JobController) RecordApplyStart(c *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
 		return
 	}
+	// Only jobs open for applications have an application form.
 	job, err := jc.jobService.GetJobByID(id)
+	if err != nil || job.Status != models.Open {
+		c.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job not found"})
+		return
+	}
+
+	jc.recordEvent(c, id, models.ApplyStarted)
+	c.JSON(http.StatusOK, utils.Response{Status: utils.Success, Message: "Event recorded"})
+}
+
+// recordEvent never fails the request; analytics are best effort.
+func (jc *JobController) recordEvent(c *gin.Context, jobID primitive.ObjectID, eventType models.JobEventType) {
+	if err := jc.analyticsService.RecordEvent(jobID, eventType, utils.VisitorID(c)); err != nil {
+		log.Printf("failed to record %s event for job %s: %v", eventType, jobID.Hex(), err)
+	}
+}
+
+func (jc *JobController) GetJobAnalytics(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Job not found"})
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
 		return
 	}
-	c.JSON(http.StatusOK, job)
+
+	funnel, err := jc.analyticsService.GetJobFunnel(id, c.Query("from"), c.Query("to"))
+	if errors.Is(err, services.ErrInvalidAnalyticsRange) {
+		c.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "from and to must be YYYY-MM-DD dates at most a year apart"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve job analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job analytics retrieved successfully",
+		Data:    funnel,
+	})
 }
 
 func (c *JobController) GetAllJobs(ctx *gin.Context) {
-	jobs, err := c.jobService.GetAllJobs()
+	var query models.JobQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{
+			Status:  utils.Error,
+			Message: "Invalid job listing query",
+		})
+		return
+	}
+
+	// With ?facets=true the response also carries counts per filter value.
+	var jobs interface{}
+	var listings []*services.JobListing
+	var err error
+	if facets, _ := strconv.ParseBool(ctx.Query("facets")); facets {
+		var result *services.JobSearchResult
+		if result, err = c.jobService.SearchJobs(&query); err == nil {
+			jobs, listings = result, result.Jobs
+		}
+	} else {
+		listings, err = c.jobService.GetAllJobs(&query)
+		jobs = listings
+	}
+	if errors.Is(err, services.ErrInvalidJobQuery) {
+		ctx.JSON(http.StatusBadRequest, utils.Response{
+			Status:  utils.Error,
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, utils.Response{
 			Status:  utils.Error,
@@ -69,6 +248,9 @@ func (c *JobController) GetAllJobs(ctx *gin.Context) {
 		return
 	}
 
+	for _, listing := range listings {
+		localize(ctx, &listing.Job)
+	}
 	ctx.JSON(http.StatusOK, utils.Response{
 		Status:  utils.Success,
 		Message: "Job listings retrieved successfully",
@@ -88,6 +270,14 @@ func (c *JobController) UpdateJob(ctx *gin.Context) {
 	}
 
 	updatedJob, err := c.jobService.UpdateJob(id, &job)
+	if errors.Is(err, services.ErrInvalidJob) {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrQuotaExceeded) {
+		ctx.JSON(http.StatusPaymentRequired, utils.Response{Status: utils.Error, Message: err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, utils.Response{
 			Status:  utils.Error,
@@ -103,6 +293,66 @@ func (c *JobController) UpdateJob(ctx *gin.Context) {
 	})
 }
 
+func (c *JobController) DuplicateJob(ctx *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{
+			Status:  utils.Error,
+			Message: "Invalid job ID",
+		})
+		return
+	}
+
+	job, err := c.jobService.DuplicateJob(id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{
+			Status:  utils.Error,
+			Message: "Failed to duplicate the job",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job duplicated as draft successfully",
+		Data:    job,
+	})
+}
+
+func (c *JobController) SetFeatured(ctx *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+	var request struct {
+		Featured *bool `json:"featured" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "featured must be true or false"})
+		return
+	}
+
+	job, err := c.jobService.SetFeatured(id, *request.Featured)
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job not found"})
+		return
+	case errors.Is(err, services.ErrQuotaExceeded):
+		ctx.JSON(http.StatusPaymentRequired, utils.Response{Status: utils.Error, Message: err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to update the job"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job updated successfully",
+		Data:    job,
+	})
+}
+
 func (c *JobController) GetRecentPostings(ctx *gin.Context) {
 	jobs, err := c.jobService.GetRecentJobs(10)
 	if err != nil {
@@ -112,6 +362,7 @@ func (c *JobController) GetRecentPostings(ctx *gin.Context) {
 		})
 		return
 	}
+	localize(ctx, jobs...)
 
 	ctx.JSON(http.StatusOK, utils.Response{
 		Status:  utils.Error,
@@ -124,6 +375,10 @@ func (c *JobController) DeleteJob(ctx *gin.Context) {
 	id := ctx.Param("id")
 
 	err := c.jobService.DeleteJob(id)
+	if errors.Is(err, services.ErrJobNotFound) {
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job not found"})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, utils.Response{
 			Status:  utils.Error,
@@ -138,6 +393,59 @@ func (c *JobController) DeleteJob(ctx *gin.Context) {
 	})
 }
 
+func (c *JobController) GetDeletedJobs(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+
+	jobs, err := c.jobService.GetDeletedJobs(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve deleted jobs"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Deleted jobs retrieved successfully",
+		Data:    jobs,
+	})
+}
+
+func (c *JobController) RestoreJob(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+
+	job, err := c.jobService.RestoreJob(id, userID)
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Deleted job not found"})
+		return
+	case errors.Is(err, services.ErrRestoreWindowExpired):
+		ctx.JSON(http.StatusGone, utils.Response{Status: utils.Error, Message: "The job was deleted too long ago to be restored"})
+		return
+	case errors.Is(err, services.ErrQuotaExceeded):
+		ctx.JSON(http.StatusPaymentRequired, utils.Response{Status: utils.Error, Message: err.Error()})
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to restore the job"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job restored successfully",
+		Data:    job,
+	})
+}
+
 func (c *JobController) GetRecommendedJobs(ctx *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
 	if err != nil {
@@ -148,7 +456,8 @@ func (c *JobController) GetRecommendedJobs(ctx *gin.Context) {
 		return
 	}
 
-	jobs, err := c.jobService.GetRecommendedJobs(id)
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	jobs, err := c.jobService.GetRecommendedJobs(id, limit)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, utils.Response{
 			Status:  utils.Error,
@@ -156,6 +465,9 @@ func (c *JobController) GetRecommendedJobs(ctx *gin.Context) {
 		})
 		return
 	}
+	for _, scored := range jobs {
+		localize(ctx, scored.Job)
+	}
 
 	ctx.JSON(http.StatusOK, utils.Response{
 		Status:  "success",
@@ -164,6 +476,32 @@ func (c *JobController) GetRecommendedJobs(ctx *gin.Context) {
 	})
 }
 
+func (c *JobController) GetJobsForMe(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	jobs, err := c.jobService.GetJobsForApplicant(userID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{
+			Status:  utils.Error,
+			Message: "Failed to retrieve personalized jobs",
+		})
+		return
+	}
+	for _, personalized := range jobs {
+		localize(ctx, personalized.Job)
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Personalized jobs retrieved successfully",
+		Data:    jobs,
+	})
+}
+
 func (c *JobController) GetJobsByCompany(ctx *gin.Context) {
 	userId, ok := utils.ExtractUserID(ctx)
 
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"errors"
+	"jobsy-api/models"
+	"jobsy-api/services"
+	"jobsy-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobTemplateController struct {
+	templateService *services.JobTemplateService
+	jobService      *services.JobService
+}
+
+func NewJobTemplateController(templateService *services.JobTemplateService, jobService *services.JobService) *JobTemplateController {
+	return &JobTemplateController{templateService: templateService, jobService: jobService}
+}
+
+func (c *JobTemplateController) CreateTemplate(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var template models.JobTemplate
+	if err := ctx.ShouldBindJSON(&template); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job template payload"})
+		return
+	}
+	template.Company = userID
+
+	created, err := c.templateService.CreateTemplate(&template)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job template created successfully",
+		Data:    created,
+	})
+}
+
+func (c *JobTemplateController) GetTemplates(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+
+	templates, err := c.templateService.GetTemplatesByCompany(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve job templates"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job templates retrieved successfully",
+		Data:    templates,
+	})
+}
+
+func (c *JobTemplateController) UpdateTemplate(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job template ID"})
+		return
+	}
+
+	var template models.JobTemplate
+	if err := ctx.ShouldBindJSON(&template); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job template payload"})
+		return
+	}
+
+	updated, err := c.templateService.UpdateTemplate(id, userID, &template)
+	if err != nil {
+		respondTemplateError(ctx, err, "Failed to update the job template")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job template updated successfully",
+		Data:    updated,
+	})
+}
+
+func (c *JobTemplateController) DeleteTemplate(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job template ID"})
+		return
+	}
+
+	if err := c.templateService.DeleteTemplate(id, userID); err != nil {
+		respondTemplateError(ctx, err, "Failed to delete the job template")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job template deleted successfully",
+	})
+}
+
+func (c *JobTemplateController) CreateJobFromTemplate(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job template ID"})
+		return
+	}
+
+	template, err := c.templateService.GetTemplateByID(id, userID)
+	if err != nil {
+		respondTemplateError(ctx, err, "Failed to retrieve the job template")
+		return
+	}
+
+	job, err := c.jobService.CreateJobFromTemplate(template)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to create job from template"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Draft job created from template",
+		Data:    job,
+	})
+}
+
+func respondTemplateError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrJobTemplateNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job template not found"})
+	default:
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: message + ": " + err.Error()})
+	}
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"errors"
+	"jobsy-api/services"
+	"jobsy-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ModerationController struct {
+	moderationService *services.ModerationService
+}
+
+func NewModerationController(moderationService *services.ModerationService) *ModerationController {
+	return &ModerationController{moderationService: moderationService}
+}
+
+func (c *ModerationController) GetQueue(ctx *gin.Context) {
+	jobs, err := c.moderationService.GetQueue()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve the moderation queue"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Moderation queue retrieved successfully",
+		Data:    jobs,
+	})
+}
+
+func (c *ModerationController) ApproveJob(ctx *gin.Context) {
+	reviewerID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+
+	job, err := c.moderationService.ApproveJob(id, reviewerID)
+	if err != nil {
+		respondModerationError(ctx, err, "Failed to approve the job")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job approved successfully",
+		Data:    job,
+	})
+}
+
+func (c *ModerationController) RejectJob(ctx *gin.Context) {
+	reviewerID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "A rejection reason is required"})
+		return
+	}
+
+	job, err := c.moderationService.RejectJob(id, reviewerID, request.Reason)
+	if err != nil {
+		respondModerationError(ctx, err, "Failed to reject the job")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job rejected successfully",
+		Data:    job,
+	})
+}
+
+func (c *ModerationController) SetCompanyVerified(ctx *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid company ID"})
+		return
+	}
+	var request struct {
+		Verified *bool `json:"verified" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "verified must be true or false"})
+		return
+	}
+
+	company, err := c.moderationService.SetCompanyVerified(userID, *request.Verified)
+	if err != nil {
+		respondModerationError(ctx, err, "Failed to update the company")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Company updated successfully",
+		Data:    company,
+	})
+}
+
+func respondModerationError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrJobNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job not found"})
+	case errors.Is(err, services.ErrCompanyNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Company not found"})
+	case errors.Is(err, services.ErrJobNotPending):
+		ctx.JSON(http.StatusConflict, utils.Response{Status: utils.Error, Message: "The job is not pending review"})
+	case errors.Is(err, services.ErrInvalidModeration):
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: message + ": " + err.Error()})
+	case errors.Is(err, services.ErrQuotaExceeded):
+		ctx.JSON(http.StatusPaymentRequired, utils.Response{Status: utils.Error, Message: message + ": " + err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: message})
+	}
+}
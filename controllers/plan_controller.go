@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"errors"
+	"jobsy-api/services"
+	"jobsy-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PlanController struct {
+	quotaService *services.QuotaService
+}
+
+func NewPlanController(quotaService *services.QuotaService) *PlanController {
+	return &PlanController{quotaService: quotaService}
+}
+
+func (c *PlanController) GetPlans(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Plans retrieved successfully",
+		Data:    c.quotaService.ListPlans(),
+	})
+}
+
+func (c *PlanController) GetMyUsage(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+
+	usage, err := c.quotaService.GetUsage(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve plan usage"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Plan usage retrieved successfully",
+		Data:    usage,
+	})
+}
+
+func (c *PlanController) GetCompanyUsage(ctx *gin.Context) {
+	companyID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid company ID"})
+		return
+	}
+
+	usage, err := c.quotaService.GetUsage(companyID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve plan usage"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Plan usage retrieved successfully",
+		Data:    usage,
+	})
+}
+
+func (c *PlanController) SetPlan(ctx *gin.Context) {
+	companyID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid company ID"})
+		return
+	}
+	var request struct {
+		Plan string `json:"plan" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid plan payload"})
+		return
+	}
+
+	subscription, err := c.quotaService.SetPlan(companyID, request.Plan)
+	if errors.Is(err, services.ErrUnknownPlan) {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to change the plan"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Plan changed successfully",
+		Data:    subscription,
+	})
+}
+
+func (c *PlanController) GrantCredits(ctx *gin.Context) {
+	companyID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid company ID"})
+		return
+	}
+	var request struct {
+		Credits int `json:"credits" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "credits must be a non-zero number"})
+		return
+	}
+
+	subscription, err := c.quotaService.GrantCredits(companyID, request.Credits)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to grant credits"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Credits granted successfully",
+		Data:    subscription,
+	})
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"errors"
+	"jobsy-api/services"
+	"jobsy-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SavedJobController struct {
+	savedJobService *services.SavedJobService
+}
+
+func NewSavedJobController(savedJobService *services.SavedJobService) *SavedJobController {
+	return &SavedJobController{savedJobService: savedJobService}
+}
+
+func (c *SavedJobController) SaveJob(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	jobID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+
+	saved, err := c.savedJobService.SaveJob(userID, jobID)
+	if errors.Is(err, services.ErrJobNotFound) {
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job not found"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to save the job"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job saved successfully",
+		Data:    saved,
+	})
+}
+
+func (c *SavedJobController) UnsaveJob(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	jobID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+
+	err = c.savedJobService.UnsaveJob(userID, jobID)
+	if errors.Is(err, services.ErrSavedJobNotFound) {
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Job is not in your saved jobs"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to remove the saved job"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Job removed from saved jobs",
+	})
+}
+
+func (c *SavedJobController) GetSavedJobs(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+
+	saved, err := c.savedJobService.GetSavedJobs(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve saved jobs"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Saved jobs retrieved successfully",
+		Data:    saved,
+	})
+}
+
+func (c *SavedJobController) GetSaveCount(ctx *gin.Context) {
+	jobID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid job ID"})
+		return
+	}
+
+	count, err := c.savedJobService.CountSaves(jobID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to count saves"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status: utils.Success,
+		Data:   gin.H{"jobId": jobID, "saves": count},
+	})
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"errors"
+	"jobsy-api/models"
+	"jobsy-api/services"
+	"jobsy-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SavedSearchController struct {
+	alertService *services.AlertService
+}
+
+func NewSavedSearchController(alertService *services.AlertService) *SavedSearchController {
+	return &SavedSearchController{alertService: alertService}
+}
+
+func (c *SavedSearchController) CreateSavedSearch(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+
+	var search models.SavedSearch
+	if err := ctx.ShouldBindJSON(&search); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid saved search payload"})
+		return
+	}
+	search.UserID = userID
+	search.Email = ctx.GetString("email")
+
+	created, err := c.alertService.CreateSavedSearch(&search)
+	if err != nil {
+		respondSavedSearchError(ctx, err, "Failed to save the search")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Search saved successfully",
+		Data:    created,
+	})
+}
+
+func (c *SavedSearchController) GetSavedSearches(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+
+	searches, err := c.alertService.GetSavedSearches(userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve saved searches"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Saved searches retrieved successfully",
+		Data:    searches,
+	})
+}
+
+func (c *SavedSearchController) UpdateSavedSearch(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid saved search ID"})
+		return
+	}
+
+	var search models.SavedSearch
+	if err := ctx.ShouldBindJSON(&search); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid saved search payload"})
+		return
+	}
+
+	updated, err := c.alertService.UpdateSavedSearch(id, userID, &search)
+	if err != nil {
+		respondSavedSearchError(ctx, err, "Failed to update the saved search")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Saved search updated successfully",
+		Data:    updated,
+	})
+}
+
+func (c *SavedSearchController) DeleteSavedSearch(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid saved search ID"})
+		return
+	}
+
+	if err := c.alertService.DeleteSavedSearch(id, userID); err != nil {
+		respondSavedSearchError(ctx, err, "Failed to delete the saved search")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Saved search deleted successfully",
+	})
+}
+
+// GetSavedSearchJobs runs a saved search now and returns the jobs it matches.
+func (c *SavedSearchController) GetSavedSearchJobs(ctx *gin.Context) {
+	userID, ok := utils.ExtractUserID(ctx)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid saved search ID"})
+		return
+	}
+
+	search, err := c.alertService.GetSavedSearchByID(id, userID)
+	if err != nil {
+		respondSavedSearchError(ctx, err, "Failed to retrieve the saved search")
+		return
+	}
+	jobs, err := c.alertService.RunSavedSearch(search)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to run the saved search"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Jobs retrieved successfully",
+		Data:    jobs,
+	})
+}
+
+func respondSavedSearchError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrSavedSearchNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Saved search not found"})
+	case errors.Is(err, services.ErrInvalidSavedSearch):
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: message + ": " + err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: message})
+	}
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"errors"
+	"jobsy-api/models"
+	"jobsy-api/services"
+	"jobsy-api/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TagController struct {
+	tagService *services.TagService
+}
+
+func NewTagController(tagService *services.TagService) *TagController {
+	return &TagController{tagService: tagService}
+}
+
+func (c *TagController) SuggestTags(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	suggestions, err := c.tagService.SuggestTags(ctx.Query("q"), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to suggest tags"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status: utils.Success,
+		Data:   suggestions,
+	})
+}
+
+func (c *TagController) GetTags(ctx *gin.Context) {
+	tags, err := c.tagService.GetTags(ctx.Query("category"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: "Failed to retrieve tags"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Tags retrieved successfully",
+		Data:    tags,
+	})
+}
+
+func (c *TagController) CreateTag(ctx *gin.Context) {
+	var tag models.Tag
+	if err := ctx.ShouldBindJSON(&tag); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid tag payload"})
+		return
+	}
+
+	created, err := c.tagService.CreateTag(&tag)
+	if err != nil {
+		respondTagError(ctx, err, "Failed to create the tag")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Tag created successfully",
+		Data:    created,
+	})
+}
+
+func (c *TagController) UpdateTag(ctx *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid tag ID"})
+		return
+	}
+
+	var tag models.Tag
+	if err := ctx.ShouldBindJSON(&tag); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid tag payload"})
+		return
+	}
+
+	updated, err := c.tagService.UpdateTag(id, &tag)
+	if err != nil {
+		respondTagError(ctx, err, "Failed to update the tag")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Tag updated successfully",
+		Data:    updated,
+	})
+}
+
+func (c *TagController) DeleteTag(ctx *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid tag ID"})
+		return
+	}
+
+	if err := c.tagService.DeleteTag(id); err != nil {
+		respondTagError(ctx, err, "Failed to delete the tag")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Tag deleted successfully",
+	})
+}
+
+func (c *TagController) MergeTags(ctx *gin.Context) {
+	var request services.TagMergeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: "Invalid merge payload"})
+		return
+	}
+
+	result, err := c.tagService.MergeTags(&request)
+	if err != nil {
+		respondTagError(ctx, err, "Failed to merge tags")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, utils.Response{
+		Status:  utils.Success,
+		Message: "Tags merged successfully",
+		Data:    result,
+	})
+}
+
+func respondTagError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrTagNotFound):
+		ctx.JSON(http.StatusNotFound, utils.Response{Status: utils.Error, Message: "Tag not found"})
+	case errors.Is(err, services.ErrInvalidTag):
+		ctx.JSON(http.StatusBadRequest, utils.Response{Status: utils.Error, Message: message + ": " + err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, utils.Response{Status: utils.Error, Message: message})
+	}
+}
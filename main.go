@@ -5,8 +5,10 @@ import (
 	"jobsy-api/controllers"
 	"jobsy-api/routes"
 	"jobsy-api/services"
+	"jobsy-api/storage"
 	"log"
 	"os"
+	"time"
 
 	"jobsy-api/config"
 
@@ -40,18 +42,115 @@ func main() {
 			return
 		}
 	}()
+	if err := services.CheckTransactions(client); err != nil {
+		log.Fatal("Unsupported MongoDB deployment: ", err)
+	}
 
-	jobService := services.NewJobService(client, "jobs")
+	jobService := services.NewJobService(client, "jobs", "applicants", "companies")
 	companyService := services.NewCompanyService(client, "companies")
-	applicantService := services.NewApplicantService(client, "applicants", "jobs")
+	applicantService := services.NewApplicantService(client, "applicants", "jobs", "users", "application_events", services.NewLogNotifier())
 	authService := services.NewAuthService(client, "users")
+	analyticsService := services.NewAnalyticsService(client, "job_events")
+	jobTemplateService := services.NewJobTemplateService(client, "job_templates")
+	syndicationService := services.NewSyndicationService(client, "jobs", companyService)
+	savedJobService := services.NewSavedJobService(client, "saved_jobs", "jobs")
+	tagService := services.NewTagService(client, "tags", "jobs")
+	quotaService := services.NewQuotaService(client, "subscriptions", "jobs")
+	alertService := services.NewAlertService(client, "saved_searches", jobService, services.NewLogNotifier(), config.PublicBaseURL())
+	moderationService := services.NewModerationService(client, "jobs", "companies", "users", jobService, services.NewLogNotifier())
+
+	var store storage.Storage
+	switch config.StorageBackend() {
+	case "s3":
+		settings := config.S3()
+		store, err = storage.NewS3(storage.S3Options(settings))
+	case "local":
+		signingKey := config.StorageSigningKey()
+		if signingKey == "" {
+			signingKey = jwtSecret
+		}
+		store, err = storage.NewLocal(config.StorageDir(), config.PublicBaseURL()+"/api/files", signingKey)
+	default:
+		log.Fatalf("Unknown storage backend %q", config.StorageBackend())
+	}
+	if err != nil {
+		log.Fatal("Failed to set up file storage:", err)
+	}
+	applicantService.SetResumeStorage(store, config.ResumeMaxBytes(), config.ResumeURLTTL())
+
+	if err := jobService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create job indexes:", err)
+	}
+	if err := companyService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create company indexes:", err)
+	}
+	if err := analyticsService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create analytics indexes:", err)
+	}
+	if err := savedJobService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create saved job indexes:", err)
+	}
+	if err := alertService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create saved search indexes:", err)
+	}
+	if err := applicantService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create applicant indexes:", err)
+	}
+	if err := tagService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create tag indexes:", err)
+	}
+	if err := tagService.Load(); err != nil {
+		log.Fatal("Failed to load tags:", err)
+	}
+
+	jobService.SetTagNormalizer(tagService)
+	applicantService.SetTagNormalizer(tagService)
+	if err := quotaService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create subscription indexes:", err)
+	}
+	jobService.SetPublishQuota(quotaService)
+	if err := moderationService.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create moderation indexes:", err)
+	}
+	jobService.SetModerator(moderationService)
+	go services.RunEvery(context.Background(), 5*time.Minute, "tag reload", tagService.Load)
+	go func() {
+		if err := tagService.CountUsage(); err != nil {
+			log.Printf("tag usage count failed: %v", err)
+		}
+		services.RunEvery(context.Background(), 15*time.Minute, "tag usage count", tagService.CountUsage)
+	}()
+
+	jobService.OnPublish(alertService.MatchJob)
+	go services.RunEvery(context.Background(), time.Hour, "job alert digests", alertService.RunDigests)
+
+	jobService.SetRetention(config.JobRetention())
+	jobService.OnPurge(savedJobService.DeleteForJobs)
+	jobService.OnPurge(analyticsService.DeleteForJobs)
+	jobService.OnPurge(applicantService.DeleteResumesForJobs)
+	jobService.OnPurge(applicantService.DeleteEventsForJobs)
+	go services.RunEvery(context.Background(), 6*time.Hour, "deleted job purge", func() error {
+		report, err := jobService.PurgeDeletedJobs()
+		if report.Jobs > 0 {
+			log.Printf("purged %d deleted jobs and %d applications", report.Jobs, report.Applications)
+		}
+		return err
+	})
 
-	jobController := controllers.NewJobController(jobService)
+	jobController := controllers.NewJobController(jobService, analyticsService)
 	companyController := controllers.NewCompanyController(companyService)
-	applicantController := controllers.NewApplicantController(applicantService)
+	applicantController := controllers.NewApplicantController(applicantService, analyticsService)
 	authController := controllers.NewAuthController(authService, jwtSecret)
+	jobTemplateController := controllers.NewJobTemplateController(jobTemplateService, jobService)
+	feedController := controllers.NewFeedController(syndicationService)
+	savedJobController := controllers.NewSavedJobController(savedJobService)
+	savedSearchController := controllers.NewSavedSearchController(alertService)
+	tagController := controllers.NewTagController(tagService)
+	planController := controllers.NewPlanController(quotaService)
+	moderationController := controllers.NewModerationController(moderationService)
+	fileController := controllers.NewFileController(store)
 
-	routes.SetupRoutes(router, jobController, companyController, applicantController, authController, jobService, authService, jwtSecret)
+	routes.SetupRoutes(router, jobController, companyController, applicantController, authController, jobTemplateController, feedController, savedJobController, savedSearchController, tagController, planController, moderationController, fileController, jobService, authService, jwtSecret)
 
 	if err := router.Run(":8080"); err != nil {
 		log.Fatal("Failed to run server:", err)
@@ -0,0 +1,273 @@
+// Package markup turns the Markdown users write in descriptions into HTML that is
+// safe to embed in a page.
+package markup
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Render converts Markdown to sanitized HTML. Besides the usual Markdown blocks
+// (paragraphs, headings, lists, quotes, code and rules) and inline styles, the
+// source may use the HTML tags Sanitize allows; anything else is stripped.
+func Render(source string) string {
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return ""
+	}
+	return Sanitize(renderBlocks(splitLines(source)))
+}
+
+func splitLines(source string) []string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\r", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+	return strings.Split(source, "\n")
+}
+
+var (
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	rulePattern     = regexp.MustCompile(`^ {0,3}(?:(?:- *){3,}|(?:\* *){3,}|(?:_ *){3,})$`)
+	fencePattern    = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	bulletPattern   = regexp.MustCompile(`^( {0,3})([-*+])( +)(.*)$`)
+	orderedPattern  = regexp.MustCompile(`^( {0,3})(\d{1,9})[.)]( +)(.*)$`)
+	quotePattern    = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	indentedPattern = regexp.MustCompile(`^ {4}`)
+)
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// startsBlock reports whether a line interrupts a paragraph.
+func startsBlock(line string) bool {
+	return headingPattern.MatchString(line) || rulePattern.MatchString(line) ||
+		fencePattern.MatchString(line) || quotePattern.MatchString(line) ||
+		bulletPattern.MatchString(line) || orderedPattern.MatchString(line)
+}
+
+func renderBlocks(lines []string) string {
+	var out strings.Builder
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+
+		case fencePattern.MatchString(line):
+			fence := fencePattern.FindStringSubmatch(line)[1]
+			var code []string
+			i++
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence[:3]) {
+				code = append(code, lines[i])
+				i++
+			}
+			i++
+			out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		case headingPattern.MatchString(line):
+			match := headingPattern.FindStringSubmatch(line)
+			level := len(match[1])
+			fmt.Fprintf(&out, "<h%d>%s</h%d>\n", level, renderInline(match[2]), level)
+			i++
+
+		case rulePattern.MatchString(line):
+			out.WriteString("<hr>\n")
+			i++
+
+		case quotePattern.MatchString(line):
+			var quoted []string
+			for i < len(lines) && !isBlank(lines[i]) {
+				if match := quotePattern.FindStringSubmatch(lines[i]); match != nil {
+					quoted = append(quoted, match[1])
+				} else {
+					quoted = append(quoted, lines[i])
+				}
+				i++
+			}
+			out.WriteString("<blockquote>\n" + renderBlocks(quoted) + "</blockquote>\n")
+
+		case bulletPattern.MatchString(line) || orderedPattern.MatchString(line):
+			var list string
+			list, i = renderList(lines, i)
+			out.WriteString(list)
+
+		case indentedPattern.MatchString(line):
+			var code []string
+			for i < len(lines) && (indentedPattern.MatchString(lines[i]) || isBlank(lines[i])) {
+				code = append(code, strings.TrimPrefix(lines[i], "    "))
+				i++
+			}
+			for len(code) > 0 && isBlank(code[len(code)-1]) {
+				code = code[:len(code)-1]
+			}
+			out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+		default:
+			var paragraph []string
+			for i < len(lines) && !isBlank(lines[i]) && (len(paragraph) == 0 || !startsBlock(lines[i])) {
+				paragraph = append(paragraph, lines[i])
+				i++
+			}
+			out.WriteString("<p>" + renderInline(strings.Join(paragraph, "\n")) + "</p>\n")
+		}
+	}
+	return out.String()
+}
+
+// listItem matches a bullet or numbered item, returning its marker kind, the
+// number, the width of the marker (the indentation its content lines need) and
+// the first line of content.
+func listItem(line string) (ordered bool, number int, width int, content string, ok bool) {
+	if match := bulletPattern.FindStringSubmatch(line); match != nil {
+		return false, 0, len(match[1]) + len(match[2]) + len(match[3]), match[4], true
+	}
+	if match := orderedPattern.FindStringSubmatch(line); match != nil {
+		number, _ = strconv.Atoi(match[2])
+		return true, number, len(match[1]) + len(match[2]) + 1 + len(match[3]), match[4], true
+	}
+	return false, 0, 0, "", false
+}
+
+// renderList renders the list starting at lines[start] and returns the index of
+// the first line after it. Items are tight (no paragraphs) unless separated by
+// blank lines.
+func renderList(lines []string, start int) (string, int) {
+	ordered, number, _, _, _ := listItem(lines[start])
+	var items [][]string
+	loose := false
+	i := start
+	for i < len(lines) {
+		itemOrdered, _, width, content, ok := listItem(lines[i])
+		if !ok || itemOrdered != ordered {
+			break
+		}
+		item := []string{content}
+		i++
+		for i < len(lines) {
+			line := lines[i]
+			if isBlank(line) {
+				// A blank line continues the item only if indented content follows.
+				if i+1 < len(lines) && strings.HasPrefix(lines[i+1], strings.Repeat(" ", width)) {
+					item = append(item, "")
+					loose = true
+					i++
+					continue
+				}
+				break
+			}
+			if strings.HasPrefix(line, strings.Repeat(" ", width)) {
+				item = append(item, line[width:])
+			} else if _, _, _, _, isItem := listItem(line); !isItem && !startsBlock(line) {
+				item = append(item, strings.TrimSpace(line))
+			} else {
+				break
+			}
+			i++
+		}
+		items = append(items, item)
+		if i < len(lines) && isBlank(lines[i]) && i+1 < len(lines) {
+			if nextOrdered, _, _, _, ok := listItem(lines[i+1]); ok && nextOrdered == ordered {
+				loose = true
+				i++
+			}
+		}
+	}
+
+	var out strings.Builder
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+		if number != 1 {
+			fmt.Fprintf(&out, "<ol start=\"%d\">\n", number)
+		} else {
+			out.WriteString("<ol>\n")
+		}
+	} else {
+		out.WriteString("<ul>\n")
+	}
+	for _, item := range items {
+		rendered := renderBlocks(item)
+		if !loose {
+			rendered = strings.TrimSuffix(rendered, "\n")
+			if strings.HasPrefix(rendered, "<p>") {
+				end := strings.Index(rendered, "</p>")
+				rendered = rendered[3:end] + rendered[end+4:]
+			}
+		}
+		out.WriteString("<li>" + rendered + "</li>\n")
+	}
+	out.WriteString("</" + tag + ">\n")
+	return out.String(), i
+}
+
+var (
+	codeSpanPattern   = regexp.MustCompile("(`+)(.+?)(`+)")
+	inlineTagPattern  = regexp.MustCompile(`</?[A-Za-z][A-Za-z0-9]*(?:\s[^<>]*)?/?>`)
+	autolinkPattern   = regexp.MustCompile(`<((?:https?://|mailto:)[^\s<>]+)>`)
+	linkPattern       = regexp.MustCompile(`!?\[([^\]]*)\]\(\s*([^\s)]+)(?:\s+"([^"]*)")?\s*\)`)
+	strongPattern     = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	emPattern         = regexp.MustCompile(`\*(\S(?:.*?\S)?)\*`)
+	underscorePattern = regexp.MustCompile(`(^|[^\w])_(\S(?:.*?\S)?)_([^\w]|$)`)
+	strikePattern     = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	hardBreakPattern  = regexp.MustCompile(`( {2,}|\\)\n`)
+	escapePattern     = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!~<>])`)
+)
+
+// renderInline renders emphasis, code, links and line breaks within a block. Code
+// spans, links and raw tags are swapped for placeholders first so that the
+// emphasis rules and escaping leave them alone.
+func renderInline(text string) string {
+	var held []string
+	hold := func(rendered string) string {
+		held = append(held, rendered)
+		return fmt.Sprintf("\x00%d\x00", len(held)-1)
+	}
+
+	text = escapePattern.ReplaceAllStringFunc(text, func(match string) string {
+		return hold(html.EscapeString(match[1:]))
+	})
+	text = codeSpanPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := codeSpanPattern.FindStringSubmatch(match)
+		if parts[1] != parts[3] {
+			return match
+		}
+		return hold("<code>" + html.EscapeString(strings.TrimSpace(parts[2])) + "</code>")
+	})
+	text = autolinkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		target := autolinkPattern.FindStringSubmatch(match)[1]
+		return hold(`<a href="` + html.EscapeString(target) + `">` + html.EscapeString(target) + "</a>")
+	})
+	text = linkPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := linkPattern.FindStringSubmatch(match)
+		label := renderInline(parts[1])
+		if label == "" {
+			label = html.EscapeString(parts[2])
+		}
+		link := `<a href="` + html.EscapeString(parts[2]) + `"`
+		if parts[3] != "" {
+			link += ` title="` + html.EscapeString(parts[3]) + `"`
+		}
+		// Images are shown as links so descriptions cannot embed tracking pixels.
+		return hold(link + ">" + label + "</a>")
+	})
+	text = inlineTagPattern.ReplaceAllStringFunc(text, hold)
+
+	text = html.EscapeString(text)
+	text = strongPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := strongPattern.FindStringSubmatch(match)
+		return "<strong>" + parts[1] + parts[2] + "</strong>"
+	})
+	text = emPattern.ReplaceAllString(text, "<em>$1</em>")
+	text = underscorePattern.ReplaceAllString(text, "$1<em>$2</em>$3")
+	text = strikePattern.ReplaceAllString(text, "<del>$1</del>")
+	text = hardBreakPattern.ReplaceAllString(text, "<br>\n")
+
+	for i := len(held) - 1; i >= 0; i-- {
+		text = strings.ReplaceAll(text, fmt.Sprintf("\x00%d\x00", i), held[i])
+	}
+	return text
+}
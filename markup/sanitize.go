@@ -0,0 +1,131 @@
+package markup
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags are the elements kept by Sanitize, with the attributes each may carry.
+var allowedTags = map[string]map[string]bool{
+	"p": {}, "br": {}, "hr": {},
+	"h1": {}, "h2": {}, "h3": {}, "h4": {}, "h5": {}, "h6": {},
+	"strong": {}, "b": {}, "em": {}, "i": {}, "u": {}, "s": {}, "del": {},
+	"code": {}, "pre": {}, "blockquote": {},
+	"ul": {}, "ol": {"start": true}, "li": {},
+	"a": {"href": true, "title": true},
+}
+
+var voidTags = map[string]bool{"br": true, "hr": true}
+
+// droppedTags are removed together with everything inside them.
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"noscript": true, "template": true, "textarea": true, "title": true,
+	"svg": true, "math": true, "select": true,
+}
+
+var allowedSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// Sanitize keeps the allowed tags and attributes of an HTML fragment and escapes
+// or drops everything else. The output is always well formed: stray end tags are
+// ignored and unclosed elements are closed.
+func Sanitize(fragment string) string {
+	var out strings.Builder
+	var open []string
+	skip := ""
+	depth := 0
+
+	tokenizer := html.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			// io.EOF at the end of the input, or a malformed tail that is dropped.
+			break
+		}
+		token := tokenizer.Token()
+
+		if skip != "" {
+			switch {
+			case tokenType == html.StartTagToken && token.Data == skip:
+				depth++
+			case tokenType == html.EndTagToken && token.Data == skip:
+				depth--
+				if depth == 0 {
+					skip = ""
+				}
+			}
+			continue
+		}
+
+		switch tokenType {
+		case html.TextToken:
+			out.WriteString(html.EscapeString(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedTags[token.Data] {
+				if tokenType == html.StartTagToken {
+					skip, depth = token.Data, 1
+				}
+				continue
+			}
+			attributes, ok := allowedTags[token.Data]
+			if !ok {
+				continue
+			}
+			out.WriteString("<" + token.Data)
+			for _, attribute := range token.Attr {
+				if !attributes[attribute.Key] || attribute.Namespace != "" {
+					continue
+				}
+				value := attribute.Val
+				if attribute.Key == "href" {
+					var safe bool
+					if value, safe = safeURL(value); !safe {
+						continue
+					}
+				}
+				out.WriteString(" " + attribute.Key + `="` + html.EscapeString(value) + `"`)
+			}
+			if token.Data == "a" {
+				out.WriteString(` rel="nofollow noopener noreferrer"`)
+			}
+			out.WriteString(">")
+			if !voidTags[token.Data] && tokenType == html.StartTagToken {
+				open = append(open, token.Data)
+			}
+		case html.EndTagToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return out.String()
+}
+
+// safeURL allows web and mail links plus relative ones. The tokenizer has already
+// decoded entities, so tricks like "jav&#x61;script:" are caught here.
+func safeURL(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if strings.ContainsAny(value, "\x00\t\n\r") {
+		return "", false
+	}
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return "", false
+	}
+	if parsed.Scheme == "" {
+		return value, !strings.Contains(strings.SplitN(value, "/", 2)[0], ":")
+	}
+	return value, allowedSchemes[strings.ToLower(parsed.Scheme)]
+}
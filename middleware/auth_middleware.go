@@ -53,6 +53,30 @@ func AuthMiddleware(authService *services.AuthService, jwtSecret string) gin.Han
 	}
 }
 
+// OptionalAuthMiddleware identifies the caller when a valid token is sent and lets
+// anonymous requests through, for public routes that show owners and admins more.
+func OptionalAuthMiddleware(authService *services.AuthService, jwtSecret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := extractToken(c)
+		if err != nil {
+			c.Next()
+			return
+		}
+		claims, err := validateToken(token, jwtSecret)
+		if err != nil {
+			c.Next()
+			return
+		}
+		user, err := authenticateUser(claims, authService)
+		if err == nil && user.Token == token {
+			c.Set("email", user.Email)
+			c.Set("role", user.Role)
+			c.Set("userId", user.ID)
+		}
+		c.Next()
+	}
+}
+
 func extractToken(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
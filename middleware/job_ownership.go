@@ -18,6 +18,7 @@ func OwnershipMiddleware(jobService *services.JobService) gin.HandlerFunc {
 				Status:  "error",
 				Message: "Invalid job ID",
 			})
+			ctx.Abort()
 			return
 		}
 		userId, exists := ctx.Get("userId") // Extract the user ID from the JWT claims
@@ -43,7 +44,7 @@ func OwnershipMiddleware(jobService *services.JobService) gin.HandlerFunc {
 		}
 
 		// Check if the job's company ID matches the logged-in user's company ID
-		if job.Company.Hex() != userId {
+		if job.Company != userId {
 			ctx.JSON(http.StatusForbidden, gin.H{
 				"status":  "error",
 				"message": "You are not authorized to perform this action on this job",
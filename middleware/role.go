@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"jobsy-api/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleMiddleware only lets users with one of the given roles through. It must run
+// after AuthMiddleware, which puts the role in the context.
+func RoleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		role := ctx.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+		ctx.JSON(http.StatusForbidden, utils.Response{
+			Status:  utils.Error,
+			Message: "You are not authorized to perform this action",
+		})
+		ctx.Abort()
+	}
+}
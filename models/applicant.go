@@ -14,6 +14,9 @@ const (
 	Hold        ApplicationStatus = "On Hold"
 	Rejected    ApplicationStatus = "Rejected"
 	Scheduled   ApplicationStatus = "Interview Scheduled"
+	Offered     ApplicationStatus = "Offer"
+	Hired       ApplicationStatus = "Hired"
+	Withdrawn   ApplicationStatus = "Withdrawn"
 )
 
 type Applicant struct {
@@ -33,7 +36,22 @@ type Applicant struct {
 	AvailabilityDate    string             `bson:"availabilityDate" json:"availabilityDate"`
 	Summary             string             `bson:"summary" json:"summary"`
 	Resume              string             `bson:"resume" json:"resume"`
+	ResumeFile          *ResumeFile        `bson:"resumeFile,omitempty" json:"resumeFile,omitempty"`
+	Location            string             `bson:"location,omitempty" json:"location,omitempty"`
 	Status              ApplicationStatus  `bson:"status" json:"status"`
+	Active              bool               `bson:"active" json:"active"`
 	CreatedAt           time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt           time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// DuplicateOf is set on an older application closed in favour of the
+	// candidate's newest one to the same job. The job's counters leave it out.
+	DuplicateOf *primitive.ObjectID `bson:"duplicateOf,omitempty" json:"duplicateOf,omitempty"`
+}
+
+// ResumeFile is a resume uploaded with the application. The file itself is only
+// reachable through a short-lived signed link.
+type ResumeFile struct {
+	Key         string `bson:"key" json:"-"`
+	Name        string `bson:"name" json:"name"`
+	ContentType string `bson:"contentType" json:"contentType"`
+	Size        int64  `bson:"size" json:"size"`
 }
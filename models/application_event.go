@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApplicationEvent records a change of an application's status: who made it, from
+// and to which status, and why. From is empty for the submission. StageType is the
+// type of the new stage when the change was made, which is what candidates see.
+type ApplicationEvent struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ApplicationID primitive.ObjectID `bson:"applicationId" json:"applicationId"`
+	JobID         primitive.ObjectID `bson:"jobId" json:"jobId"`
+	ActorID       primitive.ObjectID `bson:"actorId" json:"actorId"`
+	ActorRole     string             `bson:"actorRole" json:"actorRole"`
+	From          ApplicationStatus  `bson:"from,omitempty" json:"from,omitempty"`
+	To            ApplicationStatus  `bson:"to" json:"to"`
+	StageType     StageType          `bson:"stageType,omitempty" json:"stageType,omitempty"`
+	Note          string             `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+}
@@ -17,9 +17,15 @@ type Company struct {
 	State       string             `bson:"state" json:"state"`
 	Pincode     int                `bson:"pincode" json:"pincode"`
 	Country     string             `bson:"country" json:"country"`
+	Geo         *GeoPoint          `bson:"geo,omitempty" json:"geo,omitempty"`
 	Phone       string             `bson:"phone" json:"phone"`
 	Website     string             `bson:"website" json:"website"`
 	Description string             `bson:"description" json:"description"`
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// DescriptionHTML is Description rendered from Markdown and sanitized.
+	DescriptionHTML string    `bson:"descriptionHtml,omitempty" json:"descriptionHtml,omitempty"`
+	Verified        bool      `bson:"verified" json:"verified"`
+	CreatedAt       time.Time `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time `bson:"updatedAt" json:"updatedAt"`
+	// Pipeline is the default hiring pipeline for the company's new jobs.
+	Pipeline []PipelineStage `bson:"pipeline,omitempty" json:"pipeline,omitempty"`
 }
@@ -0,0 +1,24 @@
+package models
+
+// GeoPoint is a GeoJSON point. Coordinates are stored as [longitude, latitude] so
+// the field can be covered by a 2dsphere index.
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+func NewGeoPoint(lng, lat float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{lng, lat}}
+}
+
+func (p *GeoPoint) Lng() float64 { return p.Coordinates[0] }
+
+func (p *GeoPoint) Lat() float64 { return p.Coordinates[1] }
+
+// Valid reports whether the point has a longitude/latitude pair within range.
+func (p *GeoPoint) Valid() bool {
+	if p == nil || p.Type != "Point" || len(p.Coordinates) != 2 {
+		return false
+	}
+	return p.Lng() >= -180 && p.Lng() <= 180 && p.Lat() >= -90 && p.Lat() <= 90
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,6 +14,10 @@ const (
 	Closed JobStatus = "Closed"
 	OnHold JobStatus = "On Hold"
 	Draft  JobStatus = "Draft"
+
+	// Set by moderation only, never by the job owner.
+	PendingReview JobStatus = "Pending Review"
+	JobRejected   JobStatus = "Rejected"
 )
 
 type WorkType string
@@ -34,20 +39,79 @@ const (
 )
 
 type Job struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Title       string             `bson:"title" json:"title"`
-	Company     primitive.ObjectID `bson:"company" json:"company"`
-	Location    string             `bson:"location" json:"location"`
-	JobType     JobType            `bson:"jobType" json:"jobType"`
-	WorkType    WorkType           `bson:"workType" json:"workType"`
-	Salary      Salary             `bson:"salary" json:"salary"`
-	Summary     string             `bson:"summary" json:"summary"`
-	Description string             `bson:"description" json:"description"`
-	Applicants  int                `bson:"applicants" json:"applicants"`
-	Status      JobStatus          `bson:"status" json:"status"`
-	Tags        []string           `bson:"tags" json:"tags"`
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Title         string             `bson:"title" json:"title"`
+	Slug          string             `bson:"slug,omitempty" json:"slug,omitempty"`
+	PreviousSlugs []string           `bson:"previousSlugs,omitempty" json:"-"`
+	CanonicalURL  string             `bson:"-" json:"canonicalUrl,omitempty"`
+	Company       primitive.ObjectID `bson:"company" json:"company"`
+	Location      string             `bson:"location" json:"location"`
+	Geo           *GeoPoint          `bson:"geo,omitempty" json:"geo,omitempty"`
+	Locations     []JobLocation      `bson:"locations,omitempty" json:"locations,omitempty"`
+	JobType       JobType            `bson:"jobType" json:"jobType"`
+	WorkType      WorkType           `bson:"workType" json:"workType"`
+	Salary        Salary             `bson:"salary" json:"salary"`
+	Summary       string             `bson:"summary" json:"summary"`
+	Description   string             `bson:"description" json:"description"`
+	// DescriptionHTML is Description rendered from Markdown and sanitized. It is
+	// always derived on write and never taken from the client.
+	DescriptionHTML string           `bson:"descriptionHtml,omitempty" json:"descriptionHtml,omitempty"`
+	Language        string           `bson:"language,omitempty" json:"language,omitempty"`
+	Translations    []JobTranslation `bson:"translations,omitempty" json:"translations,omitempty"`
+	// ContentLanguage is the language the title, summary and description were
+	// served in, set when a job is localized for a public endpoint.
+	ContentLanguage string `bson:"-" json:"contentLanguage,omitempty"`
+	Applicants      int    `bson:"applicants" json:"applicants"`
+	// ApplicantCounts counts applications by status. Applicants leaves out
+	// withdrawn ones, so it is the sum of every other status.
+	ApplicantCounts map[ApplicationStatus]int `bson:"applicantCounts,omitempty" json:"applicantCounts,omitempty"`
+	Status          JobStatus                 `bson:"status" json:"status"`
+	Featured        bool                      `bson:"featured" json:"featured"`
+	Moderation      *JobModeration            `bson:"moderation,omitempty" json:"moderation,omitempty"`
+	Tags            []string                  `bson:"tags" json:"tags"`
+	CreatedAt       time.Time                 `bson:"createdAt" json:"createdAt"`
+	UpdatedAt       time.Time                 `bson:"updatedAt" json:"updatedAt"`
+	PublishedAt     *time.Time                `bson:"publishedAt,omitempty" json:"publishedAt,omitempty"`
+	DeletedAt       *time.Time                `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`
+	// Pipeline is the stages applications move through, copied from the company's
+	// default when the job is created.
+	Pipeline []PipelineStage `bson:"pipeline,omitempty" json:"pipeline,omitempty"`
+}
+
+// JobLocation is one of the places a job is hired in. Location and Geo on the job
+// mirror the first one. WorkType overrides the job's work type for this location;
+// a Remote location without a city means remote from anywhere (in Country, if set).
+type JobLocation struct {
+	City     string    `bson:"city,omitempty" json:"city,omitempty"`
+	Region   string    `bson:"region,omitempty" json:"region,omitempty"`
+	Country  string    `bson:"country,omitempty" json:"country,omitempty"`
+	WorkType WorkType  `bson:"workType,omitempty" json:"workType,omitempty"`
+	Geo      *GeoPoint `bson:"geo,omitempty" json:"geo,omitempty"`
+}
+
+// Label is how the location is shown, e.g. "Berlin, Germany" or "Remote".
+func (l JobLocation) Label() string {
+	var parts []string
+	for _, part := range []string{l.City, l.Region, l.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 && l.WorkType == Remote {
+		return string(Remote)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// JobTranslation is the text of a job in a language other than its own. Empty
+// fields fall back to the original text.
+type JobTranslation struct {
+	Language    string `bson:"language" json:"language"`
+	Title       string `bson:"title" json:"title"`
+	Summary     string `bson:"summary,omitempty" json:"summary,omitempty"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+	// DescriptionHTML is derived from Description, as on the job.
+	DescriptionHTML string `bson:"descriptionHtml,omitempty" json:"descriptionHtml,omitempty"`
 }
 
 type Salary struct {
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobEventType string
+
+const (
+	JobViewed      JobEventType = "view"
+	ApplyStarted   JobEventType = "apply_start"
+	ApplyCompleted JobEventType = "apply_complete"
+)
+
+// JobEvent records that a visitor did something with a job on a given day. There
+// is at most one event per job, type, visitor and day, which is what deduplicates
+// repeated views.
+type JobEvent struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	JobID       primitive.ObjectID `bson:"jobId" json:"jobId"`
+	Type        JobEventType       `bson:"type" json:"type"`
+	VisitorID   string             `bson:"visitorId" json:"visitorId"`
+	Day         string             `bson:"day" json:"day"`
+	FirstSeenAt time.Time          `bson:"firstSeenAt" json:"firstSeenAt"`
+	LastSeenAt  time.Time          `bson:"lastSeenAt" json:"lastSeenAt"`
+}
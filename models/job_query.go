@@ -0,0 +1,22 @@
+package models
+
+// JobQuery holds the filters accepted by the job listing and stored with saved
+// searches. Keywords must all appear in the title, summary, description or tags.
+// A radius search needs either Near (a place name or postcode) or Lat/Lng; BBox is
+// "minLng,minLat,maxLng,maxLat". Tags match any of the given tags. With Lang,
+// keywords are matched against the job text in that language only.
+type JobQuery struct {
+	Keywords string    `bson:"keywords,omitempty" json:"keywords,omitempty" form:"q"`
+	JobType  JobType   `bson:"jobType,omitempty" json:"jobType,omitempty" form:"jobType"`
+	WorkType WorkType  `bson:"workType,omitempty" json:"workType,omitempty" form:"workType"`
+	Status   JobStatus `bson:"status,omitempty" json:"status,omitempty" form:"status"`
+	Location string    `bson:"location,omitempty" json:"location,omitempty" form:"location"`
+	Tags     []string  `bson:"tags,omitempty" json:"tags,omitempty" form:"tags"`
+	Company  string    `bson:"company,omitempty" json:"company,omitempty" form:"company"`
+	Near     string    `bson:"near,omitempty" json:"near,omitempty" form:"near"`
+	Lat      *float64  `bson:"lat,omitempty" json:"lat,omitempty" form:"lat"`
+	Lng      *float64  `bson:"lng,omitempty" json:"lng,omitempty" form:"lng"`
+	RadiusKm float64   `bson:"radiusKm,omitempty" json:"radiusKm,omitempty" form:"radius"`
+	BBox     string    `bson:"bbox,omitempty" json:"bbox,omitempty" form:"bbox"`
+	Lang     string    `bson:"lang,omitempty" json:"lang,omitempty" form:"lang"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobTemplate struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company     primitive.ObjectID `bson:"company" json:"company"`
+	Name        string             `bson:"name" json:"name"`
+	Title       string             `bson:"title" json:"title"`
+	Location    string             `bson:"location" json:"location"`
+	JobType     JobType            `bson:"jobType" json:"jobType"`
+	WorkType    WorkType           `bson:"workType" json:"workType"`
+	Salary      Salary             `bson:"salary" json:"salary"`
+	Summary     string             `bson:"summary" json:"summary"`
+	Description string             `bson:"description" json:"description"`
+	Tags        []string           `bson:"tags" json:"tags"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ModerationDecision string
+
+const (
+	ModerationApproved ModerationDecision = "approved"
+	ModerationRejected ModerationDecision = "rejected"
+)
+
+// JobModeration records why a job was held for review and what the reviewer
+// decided. RequestedStatus is the status the job takes once approved. Jobs that
+// pass screening keep one too, for the text that was screened.
+//
+// Flags, Reason and ReviewedBy are for the job's owner and admins: they would tell
+// a scammer which rule caught the posting. They are left out of JSON unless Reveal
+// was called.
+type JobModeration struct {
+	RequestedStatus JobStatus          `bson:"requestedStatus" json:"requestedStatus"`
+	Flags           []string           `bson:"flags,omitempty" json:"flags,omitempty"`
+	SubmittedAt     time.Time          `bson:"submittedAt" json:"submittedAt"`
+	Decision        ModerationDecision `bson:"decision,omitempty" json:"decision,omitempty"`
+	Reason          string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	ReviewedBy      primitive.ObjectID `bson:"reviewedBy,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedAt      *time.Time         `bson:"reviewedAt,omitempty" json:"reviewedAt,omitempty"`
+	// ReviewedText is the text of the version last screened in full or approved;
+	// edits are measured against it rather than against the previous edit.
+	ReviewedText string `bson:"reviewedText,omitempty" json:"-"`
+
+	revealed bool
+}
+
+// Reveal includes Flags, Reason and ReviewedBy when the moderation is sent as JSON.
+func (m *JobModeration) Reveal() {
+	m.revealed = true
+}
+
+func (m JobModeration) MarshalJSON() ([]byte, error) {
+	type plain JobModeration
+	view := plain(m)
+	if !m.revealed {
+		view.Flags, view.Reason, view.ReviewedBy = nil, "", primitive.NilObjectID
+	}
+	return json.Marshal(view)
+}
@@ -0,0 +1,32 @@
+package models
+
+// StageType says what a pipeline stage means, whatever the company calls it.
+type StageType string
+
+const (
+	StageScreening StageType = "screening"
+	StageInterview StageType = "interview"
+	StageOffer     StageType = "offer"
+	StageHired     StageType = "hired"
+	StageRejected  StageType = "rejected"
+)
+
+// PipelineStage is one step of a job's hiring pipeline. Applications in the stage
+// have its Name as their status.
+type PipelineStage struct {
+	Name ApplicationStatus `bson:"name" json:"name"`
+	Type StageType         `bson:"type" json:"type"`
+}
+
+// DefaultPipeline is used for jobs of companies that have not set their own.
+func DefaultPipeline() []PipelineStage {
+	return []PipelineStage{
+		{Name: Pending, Type: StageScreening},
+		{Name: UnderReview, Type: StageScreening},
+		{Name: Hold, Type: StageScreening},
+		{Name: Scheduled, Type: StageInterview},
+		{Name: Offered, Type: StageOffer},
+		{Name: Hired, Type: StageHired},
+		{Name: Rejected, Type: StageRejected},
+	}
+}
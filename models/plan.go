@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Unlimited disables a plan limit.
+const Unlimited = -1
+
+// Plan limits what a company can post.
+type Plan struct {
+	Name            string `json:"name"`
+	MaxOpenJobs     int    `json:"maxOpenJobs"`
+	MonthlyPostings int    `json:"monthlyPostings"`
+	FeaturedSlots   int    `json:"featuredSlots"`
+}
+
+const DefaultPlan = "free"
+
+var Plans = map[string]Plan{
+	"free":       {Name: "free", MaxOpenJobs: 3, MonthlyPostings: 5, FeaturedSlots: 0},
+	"starter":    {Name: "starter", MaxOpenJobs: 10, MonthlyPostings: 20, FeaturedSlots: 2},
+	"business":   {Name: "business", MaxOpenJobs: 50, MonthlyPostings: 100, FeaturedSlots: 10},
+	"enterprise": {Name: "enterprise", MaxOpenJobs: Unlimited, MonthlyPostings: Unlimited, FeaturedSlots: Unlimited},
+}
+
+// Subscription is a company's plan plus any extra posting credits granted by an
+// admin. Credits are spent one per posting once the monthly allowance is used up.
+type Subscription struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Company   primitive.ObjectID `bson:"company" json:"company"`
+	Plan      string             `bson:"plan" json:"plan"`
+	Credits   int                `bson:"credits" json:"credits"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AlertFrequency string
+
+const (
+	AlertInstant AlertFrequency = "instant"
+	AlertDaily   AlertFrequency = "daily"
+	AlertWeekly  AlertFrequency = "weekly"
+)
+
+// SavedSearch is a job listing query a user wants to be alerted about. Instant
+// searches are matched as jobs are published; daily and weekly ones are sent as
+// digests of the jobs posted since LastNotifiedAt.
+type SavedSearch struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID         primitive.ObjectID `bson:"userId" json:"userId"`
+	Email          string             `bson:"email" json:"email"`
+	Name           string             `bson:"name" json:"name"`
+	Query          JobQuery           `bson:"query" json:"query"`
+	Frequency      AlertFrequency     `bson:"frequency" json:"frequency"`
+	LastNotifiedAt time.Time          `bson:"lastNotifiedAt" json:"lastNotifiedAt"`
+	CreatedAt      time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt      time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tag is an entry of the managed tag vocabulary. Jobs are stored with the
+// canonical Name; any of the Synonyms (compared case-insensitively) is rewritten
+// to it when a job is saved.
+type Tag struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Name      string             `bson:"name" json:"name"`
+	Key       string             `bson:"key" json:"-"`
+	Synonyms  []string           `bson:"synonyms" json:"synonyms"`
+	Category  string             `bson:"category,omitempty" json:"category,omitempty"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	// Usage is how many live jobs use the tag, as of the last usage count.
+	Usage int `bson:"usage" json:"usage"`
+}
@@ -11,12 +11,18 @@ type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Email     string             `json:"email" binding:"required,email"`
 	Password  string             `json:"password" binding:"required,min=6"`
-	Role      string             `bson:"role" json:"role"` // "applicant", "company"
+	Role      string             `bson:"role" json:"role"` // "applicant", "company" or "admin"
 	Token     string             `bson:"token,omitempty" json:"token"`
 	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
 }
 
+const (
+	RoleApplicant = "applicant"
+	RoleCompany   = "company"
+	RoleAdmin     = "admin"
+)
+
 // Custom errors
 var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
@@ -0,0 +1,66 @@
+package resume
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ExtractDOCX returns the text of a Word document's body, one paragraph per line.
+func ExtractDOCX(r io.ReaderAt, size int64) (string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range archive.File {
+		if file.Name != "word/document.xml" {
+			continue
+		}
+		part, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+		defer part.Close()
+		return documentText(io.LimitReader(part, maxStreamBytes)), nil
+	}
+	return "", errors.New("not a Word document")
+}
+
+// documentText collects the runs of text (w:t) in WordprocessingML, turning tabs,
+// breaks and paragraph ends into whitespace.
+func documentText(document io.Reader) string {
+	var text strings.Builder
+	decoder := xml.NewDecoder(document)
+	inText := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// The end of the document, or damage past which nothing can be read.
+			return text.String()
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteString("\t")
+			case "br", "cr":
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(element)
+			}
+		}
+	}
+}
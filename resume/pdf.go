@@ -0,0 +1,602 @@
+package resume
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	ErrEncryptedPDF = errors.New("encrypted PDFs cannot be read")
+	ErrPDFTooLarge  = errors.New("the PDF expands to too much data")
+)
+
+const (
+	// maxStreamBytes bounds each decompressed stream, so a small upload cannot
+	// expand into gigabytes.
+	maxStreamBytes = 16 << 20
+	// maxDocumentBytes bounds all decompressed and assembled content of a document,
+	// so the same stream referenced many times cannot either.
+	maxDocumentBytes = 64 << 20
+	// maxCMapEntries bounds the size of a font's ToUnicode map.
+	maxCMapEntries = 1 << 17
+)
+
+type pdfObject struct {
+	value  interface{}
+	stream []byte // raw, still encoded
+}
+
+type pdfDocument struct {
+	objects map[int]*pdfObject
+	fonts   map[int]*pdfFont
+	decoded map[*pdfObject][]byte
+	// budget is what is left of maxDocumentBytes; once it runs out, extraction
+	// stops with ErrPDFTooLarge.
+	budget    int
+	exhausted bool
+}
+
+var (
+	objectPattern  = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	encryptPattern = regexp.MustCompile(`/Encrypt\s*(?:\d+\s+\d+\s+R|<<)`)
+)
+
+// ExtractPDF returns the text of a PDF, page by page. It understands the usual
+// compressed streams, object streams and ToUnicode maps, which covers what word
+// processors and resume builders produce; scanned PDFs have no text to return.
+func ExtractPDF(r io.ReaderAt, size int64) (string, error) {
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return "", errors.New("not a PDF file")
+	}
+	if encryptPattern.Match(data) {
+		return "", ErrEncryptedPDF
+	}
+
+	doc := &pdfDocument{
+		objects: map[int]*pdfObject{},
+		fonts:   map[int]*pdfFont{},
+		decoded: map[*pdfObject][]byte{},
+		budget:  maxDocumentBytes,
+	}
+	doc.load(data)
+
+	var text strings.Builder
+	for _, page := range doc.pages() {
+		if doc.exhausted {
+			break
+		}
+		doc.pageText(page, &text)
+		text.WriteString("\n")
+	}
+	if doc.exhausted {
+		return "", ErrPDFTooLarge
+	}
+	return text.String(), nil
+}
+
+// spend takes n bytes from the document's budget and reports whether they fit.
+func (d *pdfDocument) spend(n int) bool {
+	if d.exhausted || n > d.budget {
+		d.exhausted = true
+		return false
+	}
+	d.budget -= n
+	return true
+}
+
+// load reads every "N G obj" in file order, so objects rewritten by incremental
+// updates end up with their latest version, then unpacks object streams.
+func (d *pdfDocument) load(data []byte) {
+	for _, match := range objectPattern.FindAllSubmatchIndex(data, -1) {
+		num, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		lexer := &pdfLexer{data: data, pos: match[1]}
+		value, ok := lexer.value()
+		if !ok {
+			continue
+		}
+		object := &pdfObject{value: value}
+		lexer.skipSpace()
+		if bytes.HasPrefix(data[lexer.pos:], []byte("stream")) {
+			object.stream = streamData(data, lexer.pos+len("stream"), value)
+		}
+		d.objects[num] = object
+	}
+
+	for _, object := range d.objects {
+		dict, _ := object.value.(pdfDict)
+		if dict["Type"] != pdfName("ObjStm") || d.exhausted {
+			continue
+		}
+		d.loadObjectStream(dict, d.decode(object))
+	}
+}
+
+func streamData(data []byte, start int, value interface{}) []byte {
+	if start < len(data) && data[start] == '\r' {
+		start++
+	}
+	if start < len(data) && data[start] == '\n' {
+		start++
+	}
+	if start > len(data) {
+		return nil
+	}
+	if dict, ok := value.(pdfDict); ok {
+		// A Length that is negative or runs past the file is ignored, and the
+		// stream is found by its end marker instead.
+		if length, ok := dict["Length"].(float64); ok && length >= 0 && length <= float64(len(data)-start) {
+			end := start + int(length)
+			if bytes.HasPrefix(bytes.TrimLeft(data[end:], " \r\n"), []byte("endstream")) {
+				return data[start:end]
+			}
+		}
+	}
+	end := bytes.Index(data[start:], []byte("endstream"))
+	if end < 0 {
+		return data[start:]
+	}
+	return bytes.TrimRight(data[start:start+end], "\r\n")
+}
+
+func (d *pdfDocument) loadObjectStream(dict pdfDict, content []byte) {
+	count, _ := dict["N"].(float64)
+	first, _ := dict["First"].(float64)
+	if first < 0 || first > float64(len(content)) {
+		return
+	}
+	header := &pdfLexer{data: content[:int(first)]}
+	for i := 0; i < int(count) && !header.eof(); i++ {
+		num, ok1 := header.value()
+		offset, ok2 := header.value()
+		n, isNum := num.(float64)
+		o, isOffset := offset.(float64)
+		if !ok1 || !ok2 || !isNum || !isOffset {
+			return
+		}
+		if _, exists := d.objects[int(n)]; exists || o < 0 || o >= float64(len(content))-first {
+			continue
+		}
+		lexer := &pdfLexer{data: content, pos: int(first) + int(o)}
+		if value, ok := lexer.value(); ok {
+			d.objects[int(n)] = &pdfObject{value: value}
+		}
+	}
+}
+
+func (d *pdfDocument) resolve(value interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		object, ok := d.objects[ref.num]
+		if !ok {
+			return nil
+		}
+		value = object.value
+	}
+	return nil
+}
+
+func (d *pdfDocument) dict(value interface{}) pdfDict {
+	dict, _ := d.resolve(value).(pdfDict)
+	return dict
+}
+
+// decode returns the stream's data when it is uncompressed or Flate compressed.
+// Each stream is decoded once, however often it is referenced.
+func (d *pdfDocument) decode(object *pdfObject) []byte {
+	if data, ok := d.decoded[object]; ok {
+		return data
+	}
+	data := d.decodeFilters(object)
+	d.decoded[object] = data
+	return data
+}
+
+func (d *pdfDocument) decodeFilters(object *pdfObject) []byte {
+	dict, _ := object.value.(pdfDict)
+	var filters []interface{}
+	switch filter := d.resolve(dict["Filter"]).(type) {
+	case pdfName:
+		filters = []interface{}{filter}
+	case pdfArray:
+		filters = filter
+	}
+	data := object.stream
+	for _, filter := range filters {
+		switch d.resolve(filter) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			reader, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil
+			}
+			// Keep what was read from a truncated stream.
+			data, _ = io.ReadAll(io.LimitReader(reader, int64(min(maxStreamBytes, d.budget+1))))
+			if !d.spend(len(data)) {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	return data
+}
+
+func (d *pdfDocument) streamOf(value interface{}) []byte {
+	if ref, ok := value.(pdfRef); ok {
+		if object, ok := d.objects[ref.num]; ok && object.stream != nil {
+			return d.decode(object)
+		}
+	}
+	return nil
+}
+
+// pages lists the page dictionaries in reading order, from the page tree when
+// there is a catalog and in object order otherwise.
+func (d *pdfDocument) pages() []pdfDict {
+	var pages []pdfDict
+	seen := map[int]bool{}
+	var walk func(node interface{}, depth int)
+	walk = func(node interface{}, depth int) {
+		if ref, ok := node.(pdfRef); ok {
+			if seen[ref.num] {
+				return
+			}
+			seen[ref.num] = true
+		}
+		dict := d.dict(node)
+		if dict == nil || depth > 64 {
+			return
+		}
+		if dict["Type"] == pdfName("Page") {
+			pages = append(pages, dict)
+			return
+		}
+		kids, _ := d.resolve(dict["Kids"]).(pdfArray)
+		for _, kid := range kids {
+			walk(kid, depth+1)
+		}
+	}
+	for _, object := range d.objects {
+		if dict, ok := object.value.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+			walk(dict["Pages"], 0)
+			break
+		}
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	var numbers []int
+	for num, object := range d.objects {
+		if dict, ok := object.value.(pdfDict); ok && dict["Type"] == pdfName("Page") {
+			numbers = append(numbers, num)
+		}
+	}
+	sort.Ints(numbers)
+	for _, num := range numbers {
+		pages = append(pages, d.objects[num].value.(pdfDict))
+	}
+	return pages
+}
+
+// inherited looks a page attribute up the page tree.
+func (d *pdfDocument) inherited(page pdfDict, key pdfName) interface{} {
+	for node, depth := page, 0; node != nil && depth < 64; node, depth = d.dict(node["Parent"]), depth+1 {
+		if value, ok := node[key]; ok {
+			return value
+		}
+	}
+	return nil
+}
+
+func (d *pdfDocument) pageText(page pdfDict, out *strings.Builder) {
+	var content []byte
+	switch contents := d.resolve(page["Contents"]).(type) {
+	case pdfArray:
+		for _, part := range contents {
+			stream := d.streamOf(part)
+			if !d.spend(len(stream) + 1) {
+				return
+			}
+			content = append(content, stream...)
+			content = append(content, '\n')
+		}
+	default:
+		content = d.streamOf(page["Contents"])
+	}
+	if len(content) == 0 {
+		return
+	}
+
+	fonts := map[pdfName]*pdfFont{}
+	resources := d.dict(d.inherited(page, "Resources"))
+	for name, ref := range d.dict(resources["Font"]) {
+		fonts[name] = d.font(ref)
+	}
+	runContent(content, fonts, out)
+}
+
+// runContent interprets the text operators of a content stream.
+func runContent(content []byte, fonts map[pdfName]*pdfFont, out *strings.Builder) {
+	writer := &textWriter{out: out}
+	lexer := &pdfLexer{data: content}
+	var operands []interface{}
+	var font *pdfFont
+	lastY := math.NaN()
+	for {
+		value, ok := lexer.value()
+		if !ok {
+			return
+		}
+		operator, isOperator := value.(pdfKeyword)
+		if !isOperator {
+			operands = append(operands, value)
+			continue
+		}
+		number := func(i int) float64 {
+			if i < len(operands) {
+				if n, ok := operands[i].(float64); ok {
+					return n
+				}
+			}
+			return 0
+		}
+		show := func(value interface{}) {
+			if text, ok := value.(pdfString); ok {
+				writer.text(font.decode(text))
+			}
+		}
+
+		switch operator {
+		case "Tf":
+			if len(operands) > 0 {
+				if name, ok := operands[0].(pdfName); ok {
+					font = fonts[name]
+				}
+			}
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "'":
+			writer.newline()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "\"":
+			writer.newline()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				items, _ := operands[len(operands)-1].(pdfArray)
+				for _, item := range items {
+					if adjust, ok := item.(float64); ok && adjust < -200 {
+						writer.space()
+					}
+					show(item)
+				}
+			}
+		case "Td", "TD":
+			if math.Abs(number(1)) > 0.1 {
+				writer.newline()
+			} else if number(0) > 0 {
+				writer.space()
+			}
+		case "T*":
+			writer.newline()
+		case "Tm":
+			if y := number(5); !math.IsNaN(lastY) && math.Abs(y-lastY) > 0.1 {
+				writer.newline()
+			} else {
+				writer.space()
+			}
+			lastY = number(5)
+		case "BT":
+			lastY = math.NaN()
+		case "ET":
+			writer.space()
+		case "ID":
+			// Skip inline image data up to EI.
+			for lexer.pos+2 < len(lexer.data) {
+				if isPDFSpace(lexer.data[lexer.pos]) && lexer.data[lexer.pos+1] == 'E' && lexer.data[lexer.pos+2] == 'I' &&
+					(lexer.pos+3 == len(lexer.data) || isPDFSpace(lexer.data[lexer.pos+3])) {
+					lexer.pos += 3
+					break
+				}
+				lexer.pos++
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// textWriter joins text runs, adding a space or line break only where there is
+// not one already.
+type textWriter struct {
+	out *strings.Builder
+}
+
+func (w *textWriter) last() byte {
+	s := w.out.String()
+	if s == "" {
+		return '\n'
+	}
+	return s[len(s)-1]
+}
+
+func (w *textWriter) text(s string) {
+	w.out.WriteString(s)
+}
+
+func (w *textWriter) space() {
+	if last := w.last(); last != ' ' && last != '\n' {
+		w.out.WriteByte(' ')
+	}
+}
+
+func (w *textWriter) newline() {
+	if w.last() != '\n' {
+		w.out.WriteByte('\n')
+	}
+}
+
+// pdfFont decodes shown strings to text, through the font's ToUnicode map when it
+// has one.
+type pdfFont struct {
+	toUnicode map[uint32]string
+	codeBytes int
+	composite bool
+}
+
+func (d *pdfDocument) font(ref interface{}) *pdfFont {
+	if r, ok := ref.(pdfRef); ok {
+		if cached, ok := d.fonts[r.num]; ok {
+			return cached
+		}
+	}
+	font := &pdfFont{codeBytes: 1}
+	dict := d.dict(ref)
+	if dict["Subtype"] == pdfName("Type0") {
+		font.composite = true
+		font.codeBytes = 2
+	}
+	if cmap := d.streamOf(dict["ToUnicode"]); cmap != nil {
+		font.toUnicode, font.codeBytes = parseCMap(cmap, font.codeBytes)
+	}
+	if r, ok := ref.(pdfRef); ok {
+		d.fonts[r.num] = font
+	}
+	return font
+}
+
+// winAnsi covers the WinAnsiEncoding characters that differ from Latin-1 and show
+// up in resumes: quotes, dashes and bullets.
+var winAnsi = map[byte]rune{
+	0x80: '€', 0x85: '…', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”',
+	0x95: '•', 0x96: '–', 0x97: '—', 0x99: '™',
+}
+
+func (f *pdfFont) decode(text pdfString) string {
+	if f == nil {
+		f = &pdfFont{codeBytes: 1}
+	}
+	var out strings.Builder
+	if f.toUnicode == nil {
+		// Glyph IDs of a composite font mean nothing without a map.
+		if f.composite {
+			return ""
+		}
+		for _, b := range text {
+			if r, ok := winAnsi[b]; ok {
+				out.WriteRune(r)
+			} else if b >= 0x20 || b == '\t' {
+				out.WriteRune(rune(b))
+			}
+		}
+		return out.String()
+	}
+	for i := 0; i+f.codeBytes <= len(text); i += f.codeBytes {
+		var code uint32
+		for _, b := range text[i : i+f.codeBytes] {
+			code = code<<8 | uint32(b)
+		}
+		out.WriteString(f.toUnicode[code])
+	}
+	return out.String()
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap and the code
+// length from its codespace range.
+func parseCMap(data []byte, codeBytes int) (map[uint32]string, int) {
+	mapping := map[uint32]string{}
+	lexer := &pdfLexer{data: data}
+	var operands []interface{}
+	code := func(value interface{}) (uint32, bool) {
+		s, ok := value.(pdfString)
+		if !ok || len(s) == 0 || len(s) > 4 {
+			return 0, false
+		}
+		var c uint32
+		for _, b := range s {
+			c = c<<8 | uint32(b)
+		}
+		return c, true
+	}
+	for {
+		value, ok := lexer.value()
+		if !ok {
+			return mapping, codeBytes
+		}
+		keyword, isKeyword := value.(pdfKeyword)
+		if !isKeyword {
+			operands = append(operands, value)
+			continue
+		}
+		switch keyword {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if s, ok := operands[0].(pdfString); ok && len(s) > 0 {
+					codeBytes = len(s)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if c, ok := code(operands[i]); ok {
+					if target, ok := operands[i+1].(pdfString); ok {
+						mapping[c] = utf16Text(target)
+					}
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				low, ok1 := code(operands[i])
+				high, ok2 := code(operands[i+1])
+				if !ok1 || !ok2 || high < low || high-low > 0xFFFF || len(mapping)+int(high-low) >= maxCMapEntries {
+					continue
+				}
+				switch target := operands[i+2].(type) {
+				case pdfString:
+					base := []byte(target)
+					for offset := uint32(0); offset <= high-low; offset++ {
+						current := append([]byte{}, base...)
+						if len(current) > 0 {
+							current[len(current)-1] += byte(offset)
+						}
+						mapping[low+offset] = utf16Text(current)
+					}
+				case pdfArray:
+					for j, item := range target {
+						if s, ok := item.(pdfString); ok && low+uint32(j) <= high {
+							mapping[low+uint32(j)] = utf16Text(s)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func utf16Text(data []byte) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,257 @@
+package resume
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// The PDF object model, as far as text extraction needs it.
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  []byte
+	pdfArray   []interface{}
+	pdfDict    map[pdfName]interface{}
+	pdfRef     struct{ num, gen int }
+)
+
+// pdfLexer reads PDF values and content stream operators from a byte slice.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+func (l *pdfLexer) eof() bool {
+	l.skipSpace()
+	return l.pos >= len(l.data)
+}
+
+// value reads the next value. Closing brackets come back as keywords so callers
+// reading arrays and dictionaries can stop on them; ok is false at the end.
+func (l *pdfLexer) value() (value interface{}, ok bool) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, false
+	}
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		return l.name(), true
+	case c == '(':
+		return l.literalString(), true
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return l.dict(), true
+	case c == '<':
+		return l.hexString(), true
+	case c == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return pdfKeyword(">>"), true
+	case c == '[':
+		l.pos++
+		return l.array(), true
+	case c == ']' || c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return pdfKeyword(string(c)), true
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return l.number(), true
+	default:
+		return l.keyword(), true
+	}
+}
+
+func (l *pdfLexer) token() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		// A stray delimiter; skip it so the caller makes progress.
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+func (l *pdfLexer) keyword() interface{} {
+	return pdfKeyword(l.token())
+}
+
+// number reads an integer or real, and an "N G R" reference when it starts one.
+func (l *pdfLexer) number() interface{} {
+	text := string(l.token())
+	number, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return pdfKeyword(text)
+	}
+	if num, err := strconv.Atoi(text); err == nil {
+		save := l.pos
+		l.skipSpace()
+		if l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '9' {
+			if gen, err := strconv.Atoi(string(l.token())); err == nil {
+				l.skipSpace()
+				if l.pos < len(l.data) && l.data[l.pos] == 'R' &&
+					(l.pos+1 == len(l.data) || isPDFSpace(l.data[l.pos+1]) || isPDFDelimiter(l.data[l.pos+1])) {
+					l.pos++
+					return pdfRef{num, gen}
+				}
+			}
+		}
+		l.pos = save
+	}
+	return number
+}
+
+func (l *pdfLexer) name() pdfName {
+	l.pos++
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	raw := l.data[start:l.pos]
+	if bytes.IndexByte(raw, '#') < 0 {
+		return pdfName(raw)
+	}
+	var decoded []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if b, err := strconv.ParseUint(string(raw[i+1:i+3]), 16, 8); err == nil {
+				decoded = append(decoded, byte(b))
+				i += 2
+				continue
+			}
+		}
+		decoded = append(decoded, raw[i])
+	}
+	return pdfName(decoded)
+}
+
+func (l *pdfLexer) literalString() pdfString {
+	l.pos++
+	var out []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						value = value*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(value)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (l *pdfLexer) hexString() pdfString {
+	l.pos++
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		c := l.data[l.pos]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		b, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(b)
+	}
+	return out
+}
+
+func (l *pdfLexer) array() pdfArray {
+	array := pdfArray{}
+	for {
+		value, ok := l.value()
+		if !ok || value == pdfKeyword("]") {
+			return array
+		}
+		array = append(array, value)
+	}
+}
+
+func (l *pdfLexer) dict() pdfDict {
+	dict := pdfDict{}
+	for {
+		key, ok := l.value()
+		if !ok || key == pdfKeyword(">>") {
+			return dict
+		}
+		name, isName := key.(pdfName)
+		value, ok := l.value()
+		if !ok || value == pdfKeyword(">>") {
+			return dict
+		}
+		if isName {
+			dict[name] = value
+		}
+	}
+}
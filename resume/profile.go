@@ -0,0 +1,381 @@
+// Package resume reads the text of PDF and Word resumes and picks out the details
+// an application form asks for.
+package resume
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Profile is what could be read from a resume, named like the application fields
+// it prefills. Anything not found is left empty; all of it is a best guess for the
+// candidate to check.
+type Profile struct {
+	Firstname           string   `json:"firstname,omitempty"`
+	Lastname            string   `json:"lastname,omitempty"`
+	Email               string   `json:"email,omitempty"`
+	Phone               string   `json:"phone,omitempty"`
+	LinkedinProfile     string   `json:"linkedinProfile,omitempty"`
+	CurrentJobTitle     string   `json:"currentJobTitle,omitempty"`
+	CurrentEmployerName string   `json:"currentEmployerName,omitempty"`
+	YearsOfExperience   int      `json:"yearsOfExperience,omitempty"`
+	Summary             string   `json:"summary,omitempty"`
+	Skills              []string `json:"skills"`
+}
+
+const (
+	maxSkills      = 50
+	maxSummarySize = 2000
+)
+
+var (
+	emailPattern    = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern    = regexp.MustCompile(`\+?\(?\d[\d\s().\-]{8,}\d`)
+	linkedinPattern = regexp.MustCompile(`(?i)(?:https?://)?(?:[a-z]{2,3}\.)?linkedin\.com/in/([A-Za-z0-9\-_%]+)`)
+	yearsPattern    = regexp.MustCompile(`(?i)(\d{1,2})\+?\s*(?:years|yrs)`)
+	monthPattern    = `(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?`
+	rangePattern    = regexp.MustCompile(`(?i)(?:(` + monthPattern + `)\s+|(\d{1,2})/)?((?:19|20)\d{2})\s*(?:-|–|—|to|until)\s*(?:(?:(` + monthPattern + `)\s+|(\d{1,2})/)?((?:19|20)\d{2})|(present|current|now|today))`)
+	datePattern     = regexp.MustCompile(`(?i)(?:` + monthPattern + `\s+|\d{1,2}/)?(?:19|20)\d{2}|\b(?:present|current|now|today)\b`)
+	bulletPrefix    = regexp.MustCompile(`^[\s•·▪●◦‣∙*\-–—]+`)
+	skillSeparator  = regexp.MustCompile(`[,;|•·▪●◦‣∙]`)
+)
+
+// sectionHeadings maps common resume headings to the sections Parse reads. The
+// rest only end the section before them.
+var sectionHeadings = map[string]string{
+	"experience": "experience", "work experience": "experience", "professional experience": "experience",
+	"employment": "experience", "employment history": "experience", "work history": "experience",
+	"career history": "experience", "relevant experience": "experience",
+	"skills": "skills", "technical skills": "skills", "core skills": "skills", "key skills": "skills",
+	"skills & tools": "skills", "skills and tools": "skills", "technologies": "skills",
+	"core competencies": "skills", "competencies": "skills", "tech stack": "skills", "expertise": "skills",
+	"summary": "summary", "profile": "summary", "professional summary": "summary", "about me": "summary",
+	"about": "summary", "objective": "summary", "career objective": "summary", "personal statement": "summary",
+	"education": "other", "projects": "other", "certifications": "other", "certificates": "other",
+	"languages": "other", "interests": "other", "hobbies": "other", "awards": "other",
+	"publications": "other", "references": "other", "volunteering": "other", "volunteer experience": "other",
+	"contact": "other", "contact information": "other", "personal details": "other", "courses": "other",
+}
+
+// Parse picks the application details out of a resume's text.
+func Parse(text string) *Profile {
+	profile := &Profile{Skills: []string{}}
+	lines := resumeLines(text)
+	sections := splitSections(lines)
+
+	profile.Email = emailPattern.FindString(text)
+	if match := linkedinPattern.FindStringSubmatch(text); match != nil {
+		profile.LinkedinProfile = "https://www.linkedin.com/in/" + match[1]
+	}
+	profile.Phone = findPhone(text)
+	header := sections[""]
+	var nameLine int
+	profile.Firstname, profile.Lastname, nameLine = findName(header)
+	var headline []string
+	if nameLine >= 0 {
+		headline = header[nameLine+1:]
+	}
+	profile.CurrentJobTitle, profile.CurrentEmployerName = findCurrentRole(sections["experience"], headline)
+	profile.YearsOfExperience = findYears(sections)
+	profile.Summary = findSummary(sections["summary"])
+	profile.Skills = findSkills(sections["skills"])
+	return profile
+}
+
+func resumeLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		// Tabs separate columns, e.g. a title and its dates or two skills.
+		line = strings.ReplaceAll(line, "\t", " | ")
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// heading returns the section a line starts, if it is a heading.
+func heading(line string) (string, bool) {
+	key := strings.ToLower(strings.TrimRight(strings.TrimSpace(line), ":"))
+	section, ok := sectionHeadings[key]
+	return section, ok
+}
+
+// splitSections groups lines under the section heading above them. Lines before
+// the first heading, usually the name and contact details, are under "".
+func splitSections(lines []string) map[string][]string {
+	sections := map[string][]string{}
+	current := ""
+	for _, line := range lines {
+		if section, ok := heading(line); ok {
+			current = section
+			continue
+		}
+		sections[current] = append(sections[current], line)
+	}
+	return sections
+}
+
+// findPhone returns the first number that has the digits of a phone number, as
+// "+" and digits the way applications store it.
+func findPhone(text string) string {
+	for _, candidate := range phonePattern.FindAllString(text, -1) {
+		if rangePattern.MatchString(candidate) {
+			continue
+		}
+		var phone strings.Builder
+		if strings.HasPrefix(strings.TrimSpace(candidate), "+") {
+			phone.WriteByte('+')
+		}
+		digits := 0
+		for _, r := range candidate {
+			if r >= '0' && r <= '9' {
+				phone.WriteRune(r)
+				digits++
+			}
+		}
+		if digits >= 10 && digits <= 15 {
+			return phone.String()
+		}
+	}
+	return ""
+}
+
+// findName takes the first line near the top that reads like a person's name, and
+// returns its index, or -1.
+func findName(header []string) (string, string, int) {
+	for i, line := range header {
+		if i >= 6 {
+			break
+		}
+		if strings.Contains(line, "@") || strings.ContainsAny(line, "0123456789|,:/") {
+			continue
+		}
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "resume") || strings.Contains(lower, "curriculum") || lower == "cv" {
+			continue
+		}
+		words := strings.Fields(line)
+		if len(words) < 2 || len(words) > 4 || !nameWords(words) {
+			continue
+		}
+		if strings.ToUpper(line) == line {
+			for i, word := range words {
+				words[i] = titleCase(word)
+			}
+		}
+		return words[0], strings.Join(words[1:], " "), i
+	}
+	return "", "", -1
+}
+
+func nameWords(words []string) bool {
+	for _, word := range words {
+		first := []rune(word)[0]
+		if !unicode.IsUpper(first) {
+			return false
+		}
+		for _, r := range word {
+			if !unicode.IsLetter(r) && r != '-' && r != '\'' && r != '.' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func titleCase(word string) string {
+	runes := []rune(strings.ToLower(word))
+	for i, r := range runes {
+		if i == 0 || runes[i-1] == '-' || runes[i-1] == '\'' {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+// findCurrentRole reads the first entry of the experience section, written as
+// "Title at Company", "Title, Company", "Title | Company" or as a title line above
+// a company line. Without an experience section the headline under the name is
+// tried.
+func findCurrentRole(experience, headline []string) (string, string) {
+	var candidates []string
+	for _, line := range experience {
+		if len(candidates) == 3 {
+			break
+		}
+		if stripDates(line) == "" {
+			continue
+		}
+		candidates = append(candidates, stripDates(line))
+	}
+	if len(candidates) > 0 {
+		if title, employer, ok := splitRole(candidates[0]); ok {
+			return title, employer
+		}
+		if len(candidates) > 1 {
+			if _, _, ok := splitRole(candidates[1]); !ok && len(strings.Fields(candidates[1])) <= 6 {
+				return candidates[0], candidates[1]
+			}
+		}
+		return candidates[0], ""
+	}
+
+	for i, line := range headline {
+		if i >= 3 {
+			break
+		}
+		if strings.Contains(line, "@") || findPhone(line) != "" || linkedinPattern.MatchString(line) {
+			continue
+		}
+		if title, employer, ok := splitRole(line); ok && strings.Contains(strings.ToLower(line), " at ") {
+			return title, employer
+		}
+		if len(strings.Fields(line)) <= 6 {
+			return line, ""
+		}
+	}
+	return "", ""
+}
+
+var roleSeparators = []string{" at ", " @ ", " | ", " – ", " — ", " - ", ", "}
+
+func splitRole(line string) (string, string, bool) {
+	lower := strings.ToLower(line)
+	for _, separator := range roleSeparators {
+		if i := strings.Index(lower, separator); i > 0 {
+			title := strings.TrimSpace(line[:i])
+			employer := strings.TrimSpace(line[i+len(separator):])
+			// "Company, City" after the first split is just a location.
+			if j := strings.Index(employer, ", "); j > 0 && separator != ", " {
+				employer = employer[:j]
+			}
+			if title != "" && employer != "" {
+				return title, employer, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// stripDates removes dates and date ranges, and the separators left around them.
+func stripDates(line string) string {
+	line = rangePattern.ReplaceAllString(line, "")
+	line = datePattern.ReplaceAllString(line, "")
+	line = bulletPrefix.ReplaceAllString(line, "")
+	return strings.Trim(strings.TrimSpace(line), "|,–—-()· ")
+}
+
+// findYears prefers a stated "N years" in the summary or header, and otherwise adds
+// up the date ranges of the experience section, counting overlaps once.
+func findYears(sections map[string][]string) int {
+	for _, section := range []string{"summary", ""} {
+		if match := yearsPattern.FindStringSubmatch(strings.Join(sections[section], " ")); match != nil {
+			years, _ := strconv.Atoi(match[1])
+			return years
+		}
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	now := time.Now()
+	for _, match := range rangePattern.FindAllStringSubmatch(strings.Join(sections["experience"], "\n"), -1) {
+		startYear, _ := strconv.Atoi(match[3])
+		start := startYear*12 + month(match[1], match[2], 1) - 1
+		end := now.Year()*12 + int(now.Month()) - 1
+		if match[7] == "" {
+			endYear, _ := strconv.Atoi(match[6])
+			end = endYear*12 + month(match[4], match[5], 1) - 1
+		}
+		if end > start {
+			spans = append(spans, span{start, end})
+		}
+	}
+	if len(spans) == 0 {
+		return 0
+	}
+
+	// Merge overlapping spans; they are few, so a simple sort is enough.
+	for i := 1; i < len(spans); i++ {
+		for j := i; j > 0 && spans[j].start < spans[j-1].start; j-- {
+			spans[j], spans[j-1] = spans[j-1], spans[j]
+		}
+	}
+	months := 0
+	current := spans[0]
+	for _, next := range spans[1:] {
+		if next.start <= current.end {
+			if next.end > current.end {
+				current.end = next.end
+			}
+			continue
+		}
+		months += current.end - current.start
+		current = next
+	}
+	months += current.end - current.start
+	return int(math.Round(float64(months) / 12))
+}
+
+// month reads a month name or number, defaulting when there is neither.
+func month(name, number string, fallback int) int {
+	if number != "" {
+		if n, err := strconv.Atoi(number); err == nil && n >= 1 && n <= 12 {
+			return n
+		}
+	}
+	if len(name) >= 3 {
+		months := []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+		prefix := strings.ToLower(name[:3])
+		for i, m := range months {
+			if m == prefix {
+				return i + 1
+			}
+		}
+	}
+	return fallback
+}
+
+func findSummary(lines []string) string {
+	summary := strings.Join(lines, " ")
+	if len(summary) > maxSummarySize {
+		summary = summary[:maxSummarySize]
+		if i := strings.LastIndex(summary, " "); i > 0 {
+			summary = summary[:i]
+		}
+	}
+	return summary
+}
+
+// findSkills splits the skills section into single skills, dropping labels like
+// "Languages:" and anything too long to be a skill.
+func findSkills(lines []string) []string {
+	skills := []string{}
+	seen := map[string]bool{}
+	for _, line := range lines {
+		if i := strings.Index(line, ":"); i > 0 && i < 30 {
+			line = line[i+1:]
+		}
+		for _, item := range skillSeparator.Split(line, -1) {
+			item = strings.TrimSpace(bulletPrefix.ReplaceAllString(item, ""))
+			item = strings.TrimRight(item, ".")
+			if item == "" || len(item) > 40 || len(strings.Fields(item)) > 4 {
+				continue
+			}
+			key := strings.ToLower(item)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			skills = append(skills, item)
+			if len(skills) == maxSkills {
+				return skills
+			}
+		}
+	}
+	return skills
+}
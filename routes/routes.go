@@ -3,6 +3,7 @@ package routes
 import (
 	"jobsy-api/controllers"
 	"jobsy-api/middleware"
+	"jobsy-api/models"
 	"jobsy-api/services"
 
 	"github.com/gin-gonic/gin"
@@ -14,6 +15,14 @@ func SetupRoutes(
 	companyController *controllers.CompanyController,
 	applicantController *controllers.ApplicantController,
 	authController *controllers.AuthController,
+	jobTemplateController *controllers.JobTemplateController,
+	feedController *controllers.FeedController,
+	savedJobController *controllers.SavedJobController,
+	savedSearchController *controllers.SavedSearchController,
+	tagController *controllers.TagController,
+	planController *controllers.PlanController,
+	moderationController *controllers.ModerationController,
+	fileController *controllers.FileController,
 	jobService *services.JobService,
 	authService *services.AuthService,
 	jwtSecret string,
@@ -22,10 +31,28 @@ func SetupRoutes(
 	router.POST("/auth/login", authController.Login)
 
 	public := router.Group("/api")
+	optionalAuth := middleware.OptionalAuthMiddleware(authService, jwtSecret)
 	public.GET("/jobs", jobController.GetAllJobs)
 	public.GET("/jobs/recent", jobController.GetRecentPostings)
-	public.GET("/jobs/:id", jobController.GetJobByID)
+	public.GET("/jobs/:id", optionalAuth, jobController.GetJobByID)
 	public.GET("/jobs/recommended/:id", jobController.GetRecommendedJobs)
+	public.GET("/jobs/:id/jsonld", feedController.GetJobPosting)
+	public.POST("/jobs/:id/apply-start", jobController.RecordApplyStart)
+
+	// Syndication feeds
+	public.GET("/feeds/jobs.rss", feedController.GetRSSFeed)
+	public.GET("/feeds/jobs.atom", feedController.GetAtomFeed)
+	public.GET("/feeds/jobs.xml", feedController.GetXMLFeed)
+
+	// Tags
+	public.GET("/tags", tagController.GetTags)
+	public.GET("/tags/suggest", tagController.SuggestTags)
+
+	// Plans
+	public.GET("/plans", planController.GetPlans)
+
+	// Files behind signed links
+	public.GET("/files/*key", fileController.ServeFile)
 
 	public.POST("/companies", companyController.CreateCompany)
 
@@ -34,20 +61,72 @@ func SetupRoutes(
 
 	// Jobs Routes
 	auth.POST("/jobs", jobController.CreateJob)
+	auth.POST("/jobs/import", jobController.ImportJobs)
 
 	auth.GET("/jobs/company", jobController.GetJobsByCompany)
+	auth.GET("/jobs/for-me", jobController.GetJobsForMe)
+	auth.GET("/jobs/deleted", jobController.GetDeletedJobs)
+	auth.POST("/jobs/:id/restore", jobController.RestoreJob)
 	auth.PUT("/jobs/:id", middleware.OwnershipMiddleware(jobService), jobController.UpdateJob)
 	auth.DELETE("/jobs/:id", middleware.OwnershipMiddleware(jobService), jobController.DeleteJob)
+	auth.POST("/jobs/:id/duplicate", middleware.OwnershipMiddleware(jobService), jobController.DuplicateJob)
+	auth.GET("/jobs/:id/analytics", middleware.OwnershipMiddleware(jobService), jobController.GetJobAnalytics)
+	auth.GET("/jobs/:id/saves", middleware.OwnershipMiddleware(jobService), savedJobController.GetSaveCount)
+	auth.GET("/jobs/:id/applicants/locations", middleware.OwnershipMiddleware(jobService), applicantController.GetLocationBreakdown)
+	auth.GET("/jobs/:id/pipeline", middleware.OwnershipMiddleware(jobService), applicantController.GetPipelineBoard)
+	auth.PUT("/jobs/:id/featured", middleware.OwnershipMiddleware(jobService), jobController.SetFeatured)
+
+	// Saved Jobs Routes
+	auth.GET("/saved-jobs", savedJobController.GetSavedJobs)
+	auth.POST("/saved-jobs/:id", savedJobController.SaveJob)
+	auth.DELETE("/saved-jobs/:id", savedJobController.UnsaveJob)
+
+	// Saved Searches Routes
+	auth.POST("/saved-searches", savedSearchController.CreateSavedSearch)
+	auth.GET("/saved-searches", savedSearchController.GetSavedSearches)
+	auth.PUT("/saved-searches/:id", savedSearchController.UpdateSavedSearch)
+	auth.DELETE("/saved-searches/:id", savedSearchController.DeleteSavedSearch)
+	auth.GET("/saved-searches/:id/jobs", savedSearchController.GetSavedSearchJobs)
+
+	// Job Templates Routes
+	auth.POST("/job-templates", jobTemplateController.CreateTemplate)
+	auth.GET("/job-templates", jobTemplateController.GetTemplates)
+	auth.PUT("/job-templates/:id", jobTemplateController.UpdateTemplate)
+	auth.DELETE("/job-templates/:id", jobTemplateController.DeleteTemplate)
+	auth.POST("/job-templates/:id/jobs", jobTemplateController.CreateJobFromTemplate)
 
 	// Companies Routes
+	auth.GET("/companies/me/usage", middleware.RoleMiddleware(models.RoleCompany), planController.GetMyUsage)
+	auth.PUT("/companies/me/pipeline", middleware.RoleMiddleware(models.RoleCompany), companyController.SetDefaultPipeline)
 	auth.GET("/companies/:id", companyController.GetCompanyByID)
 
 	// Applicants Routes
 	auth.POST("/applicants", applicantController.CreateApplicant)
-	auth.GET("/applicants/job/:id", applicantController.GetApplicantsByJobID)
+	auth.POST("/applicants/parse-resume", applicantController.ParseResume)
+	auth.GET("/applicants/job/:id", middleware.OwnershipMiddleware(jobService), applicantController.GetApplicantsByJobID)
 	auth.GET("/applicants/:id", applicantController.GetApplicantByID)
 	auth.PUT("/applicants/:id", applicantController.UpdateApplicantStatus)
 	auth.POST("/applicants/:id", applicantController.UpdateApplicantStatus)
+	auth.PATCH("/applicants/:id", applicantController.UpdateApplication)
+	auth.POST("/applicants/:id/withdraw", applicantController.WithdrawApplication)
+	auth.GET("/applicants/:id/timeline", applicantController.GetTimeline)
+	auth.DELETE("/applicants/:id", applicantController.DeleteApplicant)
+	auth.GET("/applicants/:id/resume", middleware.RoleMiddleware(models.RoleCompany), applicantController.GetResumeLink)
+
+	// Admin Routes
+	admin := auth.Group("/admin", middleware.RoleMiddleware(models.RoleAdmin))
+	admin.POST("/tags", tagController.CreateTag)
+	admin.PUT("/tags/:id", tagController.UpdateTag)
+	admin.DELETE("/tags/:id", tagController.DeleteTag)
+	admin.POST("/tags/merge", tagController.MergeTags)
+	admin.GET("/companies/:id/usage", planController.GetCompanyUsage)
+	admin.PUT("/companies/:id/plan", planController.SetPlan)
+	admin.POST("/companies/:id/credits", planController.GrantCredits)
+	admin.PUT("/companies/:id/verified", moderationController.SetCompanyVerified)
+	admin.GET("/moderation/jobs", moderationController.GetQueue)
+	admin.POST("/moderation/jobs/:id/approve", moderationController.ApproveJob)
+	admin.POST("/moderation/jobs/:id/reject", moderationController.RejectJob)
+	admin.POST("/applicants/reconcile", applicantController.ReconcileCounts)
 
 	// Logout
 	auth.POST("/auth/logout", authController.Logout)
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsy-api/models"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	MaxSavedSearchesPerUser = 20
+	maxDigestJobs           = 20
+	alertNotifyTimeout      = 30 * time.Second
+)
+
+var (
+	ErrInvalidSavedSearch  = errors.New("invalid saved search")
+	ErrSavedSearchNotFound = errors.New("saved search not found")
+)
+
+// alertPeriods is how long a digest search waits between notifications.
+var alertPeriods = map[models.AlertFrequency]time.Duration{
+	models.AlertDaily:  24 * time.Hour,
+	models.AlertWeekly: 7 * 24 * time.Hour,
+}
+
+type AlertService struct {
+	searchCollection *mongo.Collection
+	jobService       *JobService
+	notifier         Notifier
+	baseURL          string
+}
+
+func NewAlertService(db *mongo.Client, collection string, jobService *JobService, notifier Notifier, baseURL string) *AlertService {
+	return &AlertService{
+		searchCollection: db.Database("jobsy-api").Collection(collection),
+		jobService:       jobService,
+		notifier:         notifier,
+		baseURL:          baseURL,
+	}
+}
+
+func (s *AlertService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.searchCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "frequency", Value: 1}, {Key: "lastNotifiedAt", Value: 1}}},
+	})
+	return err
+}
+
+func (s *AlertService) CreateSavedSearch(search *models.SavedSearch) (*models.SavedSearch, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if err := s.validateSavedSearch(search); err != nil {
+		return nil, err
+	}
+	count, err := s.searchCollection.CountDocuments(ctx, bson.M{"userId": search.UserID})
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxSavedSearchesPerUser {
+		return nil, fmt.Errorf("%w: at most %d saved searches per user", ErrInvalidSavedSearch, MaxSavedSearchesPerUser)
+	}
+
+	now := time.Now()
+	search.ID = primitive.NewObjectID()
+	search.CreatedAt = now
+	search.UpdatedAt = now
+	// Only jobs posted from now on are sent, not the whole back catalogue.
+	search.LastNotifiedAt = now
+	if _, err := s.searchCollection.InsertOne(ctx, search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+func (s *AlertService) GetSavedSearches(userID primitive.ObjectID) ([]*models.SavedSearch, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := s.searchCollection.Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	searches := []*models.SavedSearch{}
+	if err = cursor.All(ctx, &searches); err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// GetSavedSearchByID only returns the search when it belongs to the given user.
+func (s *AlertService) GetSavedSearchByID(id, userID primitive.ObjectID) (*models.SavedSearch, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var search models.SavedSearch
+	err := s.searchCollection.FindOne(ctx, bson.M{"_id": id, "userId": userID}).Decode(&search)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSavedSearchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
+
+// UpdateSavedSearch replaces the name, query and frequency of a user's search.
+func (s *AlertService) UpdateSavedSearch(id, userID primitive.ObjectID, search *models.SavedSearch) (*models.SavedSearch, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if err := s.validateSavedSearch(search); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{
+		"name":      search.Name,
+		"query":     search.Query,
+		"frequency": search.Frequency,
+		"updatedAt": time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.SavedSearch
+	err := s.searchCollection.FindOneAndUpdate(ctx, bson.M{"_id": id, "userId": userID}, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrSavedSearchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *AlertService) DeleteSavedSearch(id, userID primitive.ObjectID) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	result, err := s.searchCollection.DeleteOne(ctx, bson.M{"_id": id, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// RunSavedSearch returns the Open jobs currently matching a saved search.
+func (s *AlertService) RunSavedSearch(search *models.SavedSearch) ([]*JobListing, error) {
+	query := search.Query
+	query.Status = models.Open
+	return s.jobService.GetAllJobs(&query)
+}
+
+func (s *AlertService) validateSavedSearch(search *models.SavedSearch) error {
+	search.Name = strings.TrimSpace(search.Name)
+	if search.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSavedSearch)
+	}
+	if search.Frequency == "" {
+		search.Frequency = models.AlertDaily
+	}
+	if _, ok := alertPeriods[search.Frequency]; !ok && search.Frequency != models.AlertInstant {
+		return fmt.Errorf("%w: frequency must be instant, daily or weekly", ErrInvalidSavedSearch)
+	}
+	// Alerts are only ever about Open jobs, so a status filter would be meaningless.
+	search.Query.Status = ""
+	if err := s.jobService.ValidateQuery(&search.Query); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSavedSearch, err)
+	}
+	return nil
+}
+
+// MatchJob is registered as a JobService publish hook. It notifies every instant
+// search the newly published job matches.
+func (s *AlertService) MatchJob(job *models.Job) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	cursor, err := s.searchCollection.Find(ctx, bson.M{"frequency": models.AlertInstant})
+	if err != nil {
+		log.Printf("job alerts: failed to load instant searches for job %s: %v", job.ID.Hex(), err)
+		return
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var search models.SavedSearch
+		if err := cursor.Decode(&search); err != nil {
+			log.Printf("job alerts: failed to decode saved search: %v", err)
+			continue
+		}
+		if search.UserID == job.Company || !s.jobService.jobMatchesQuery(&search.Query, job) {
+			continue
+		}
+		listing := &JobListing{Job: *job}
+		if err := s.notify(&search, []*JobListing{listing}, time.Now()); err != nil {
+			log.Printf("job alerts: failed to notify saved search %s: %v", search.ID.Hex(), err)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("job alerts: failed to read instant searches: %v", err)
+	}
+}
+
+// RunDigests sends daily and weekly digests for every search whose period has
+// elapsed. Searches without new jobs are skipped but still move their window on,
+// so a quiet week does not turn into a huge digest later.
+func (s *AlertService) RunDigests() error {
+	var errs []error
+	for frequency, period := range alertPeriods {
+		if err := s.runDigests(frequency, period); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *AlertService) runDigests(frequency models.AlertFrequency, period time.Duration) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	now := time.Now()
+	filter := bson.M{"frequency": frequency, "lastNotifiedAt": bson.M{"$lte": now.Add(-period)}}
+	cursor, err := s.searchCollection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var searches []*models.SavedSearch
+	if err = cursor.All(ctx, &searches); err != nil {
+		return err
+	}
+
+	for _, search := range searches {
+		jobs, err := s.jobService.findJobsPostedSince(&search.Query, search.LastNotifiedAt, maxDigestJobs)
+		if err != nil {
+			log.Printf("job alerts: failed to run saved search %s: %v", search.ID.Hex(), err)
+			continue
+		}
+		if len(jobs) == 0 {
+			err = s.markNotified(search.ID, now)
+		} else {
+			err = s.notify(search, jobs, now)
+		}
+		if err != nil {
+			log.Printf("job alerts: failed to send digest for saved search %s: %v", search.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func (s *AlertService) notify(search *models.SavedSearch, jobs []*JobListing, at time.Time) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), alertNotifyTimeout)
+	defer cancel()
+	if lang, ok := baseLanguage(search.Query.Lang); ok {
+		for _, listing := range jobs {
+			LocalizeJobs([]string{lang}, &listing.Job)
+		}
+	}
+
+	subject := fmt.Sprintf("New job for %q: %s", search.Name, jobs[0].Title)
+	if len(jobs) > 1 {
+		subject = fmt.Sprintf("%d new jobs for %q", len(jobs), search.Name)
+	}
+	var body strings.Builder
+	for _, listing := range jobs {
+		fmt.Fprintf(&body, "%s", listing.Title)
+		if listing.Location != "" {
+			fmt.Fprintf(&body, " - %s", listing.Location)
+		}
+		fmt.Fprintf(&body, "\n%s\n\n", jobURL(s.baseURL, &listing.Job))
+	}
+
+	err := s.notifier.Notify(ctx, Notification{
+		UserID:  search.UserID,
+		To:      search.Email,
+		Subject: subject,
+		Body:    body.String(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.markNotified(search.ID, at)
+}
+
+func (s *AlertService) markNotified(id primitive.ObjectID, at time.Time) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.searchCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"lastNotifiedAt": at}})
+	return err
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	analyticsDayLayout     = "2006-01-02"
+	DefaultAnalyticsDays   = 30
+	MaxAnalyticsDays       = 366
+	analyticsRecordTimeout = 5 * time.Second
+)
+
+var ErrInvalidAnalyticsRange = errors.New("invalid analytics date range")
+
+type FunnelStats struct {
+	Views          int     `json:"views"`
+	UniqueVisitors int     `json:"uniqueVisitors"`
+	ApplyStarts    int     `json:"applyStarts"`
+	Applications   int     `json:"applications"`
+	ConversionRate float64 `json:"conversionRate"`
+}
+
+type DailyFunnel struct {
+	Day string `json:"day"`
+	FunnelStats
+}
+
+type JobFunnel struct {
+	JobID  primitive.ObjectID `json:"jobId"`
+	From   string             `json:"from"`
+	To     string             `json:"to"`
+	Totals FunnelStats        `json:"totals"`
+	Daily  []DailyFunnel      `json:"daily"`
+}
+
+type AnalyticsService struct {
+	eventCollection *mongo.Collection
+}
+
+func NewAnalyticsService(db *mongo.Client, collection string) *AnalyticsService {
+	return &AnalyticsService{
+		eventCollection: db.Database("jobsy-api").Collection(collection),
+	}
+}
+
+func (s *AnalyticsService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.eventCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "jobId", Value: 1}, {Key: "type", Value: 1}, {Key: "visitorId", Value: 1}, {Key: "day", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "jobId", Value: 1}, {Key: "day", Value: 1}}},
+	})
+	return err
+}
+
+// RecordEvent stores an event at most once per job, type, visitor and UTC day.
+func (s *AnalyticsService) RecordEvent(jobID primitive.ObjectID, eventType models.JobEventType, visitorID string) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), analyticsRecordTimeout)
+	defer cancel()
+	now := time.Now().UTC()
+	filter := bson.M{
+		"jobId":     jobID,
+		"type":      eventType,
+		"visitorId": visitorID,
+		"day":       now.Format(analyticsDayLayout),
+	}
+	update := bson.M{
+		"$setOnInsert": bson.M{"firstSeenAt": now},
+		"$set":         bson.M{"lastSeenAt": now},
+	}
+	_, err := s.eventCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// Two concurrent upserts for the same visitor; the other one won.
+		return nil
+	}
+	return err
+}
+
+// DeleteForJobs removes the events of the given jobs. It is registered as a
+// JobService purge hook.
+func (s *AnalyticsService) DeleteForJobs(ctx context.Context, jobIDs []primitive.ObjectID) error {
+	_, err := s.eventCollection.DeleteMany(ctx, bson.M{"jobId": bson.M{"$in": jobIDs}})
+	return err
+}
+
+// GetJobFunnel returns views, unique visitors, apply starts and applications per
+// day for a job between from and to (inclusive, "YYYY-MM-DD"). Empty bounds
+// default to the last DefaultAnalyticsDays days.
+func (s *AnalyticsService) GetJobFunnel(jobID primitive.ObjectID, from, to string) (*JobFunnel, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	start, end, err := analyticsRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{
+		"jobId": jobID,
+		"day":   bson.M{"$gte": start.Format(analyticsDayLayout), "$lte": end.Format(analyticsDayLayout)},
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$facet", Value: bson.M{
+			"daily": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"day": "$day", "type": "$type"},
+					"count": bson.M{"$sum": 1},
+				}},
+			},
+			"visitors": bson.A{
+				bson.M{"$match": bson.M{"type": models.JobViewed}},
+				bson.M{"$group": bson.M{"_id": "$visitorId"}},
+				bson.M{"$count": "count"},
+			},
+		}}},
+	}
+	cursor, err := s.eventCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []struct {
+		Daily []struct {
+			ID struct {
+				Day  string              `bson:"day"`
+				Type models.JobEventType `bson:"type"`
+			} `bson:"_id"`
+			Count int `bson:"count"`
+		} `bson:"daily"`
+		Visitors []struct {
+			Count int `bson:"count"`
+		} `bson:"visitors"`
+	}
+	if err = cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	funnel := &JobFunnel{JobID: jobID, From: start.Format(analyticsDayLayout), To: end.Format(analyticsDayLayout), Daily: []DailyFunnel{}}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		funnel.Daily = append(funnel.Daily, DailyFunnel{Day: day.Format(analyticsDayLayout)})
+	}
+	days := make(map[string]*DailyFunnel, len(funnel.Daily))
+	for i := range funnel.Daily {
+		days[funnel.Daily[i].Day] = &funnel.Daily[i]
+	}
+
+	if len(result) > 0 {
+		for _, row := range result[0].Daily {
+			daily, ok := days[row.ID.Day]
+			if !ok {
+				continue
+			}
+			switch row.ID.Type {
+			case models.JobViewed:
+				// A view is stored once per visitor per day, so the daily view count is
+				// also the number of unique visitors that day.
+				daily.Views += row.Count
+				daily.UniqueVisitors += row.Count
+				funnel.Totals.Views += row.Count
+			case models.ApplyStarted:
+				daily.ApplyStarts += row.Count
+				funnel.Totals.ApplyStarts += row.Count
+			case models.ApplyCompleted:
+				daily.Applications += row.Count
+				funnel.Totals.Applications += row.Count
+			}
+		}
+		if len(result[0].Visitors) > 0 {
+			funnel.Totals.UniqueVisitors = result[0].Visitors[0].Count
+		}
+	}
+
+	for i := range funnel.Daily {
+		funnel.Daily[i].ConversionRate = conversionRate(funnel.Daily[i].Applications, funnel.Daily[i].UniqueVisitors)
+	}
+	funnel.Totals.ConversionRate = conversionRate(funnel.Totals.Applications, funnel.Totals.UniqueVisitors)
+	return funnel, nil
+}
+
+func analyticsRange(from, to string) (time.Time, time.Time, error) {
+	end := time.Now().UTC().Truncate(24 * time.Hour)
+	if to != "" {
+		parsed, err := time.Parse(analyticsDayLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidAnalyticsRange
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, -(DefaultAnalyticsDays - 1))
+	if from != "" {
+		parsed, err := time.Parse(analyticsDayLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidAnalyticsRange
+		}
+		start = parsed
+	}
+	if start.After(end) || end.Sub(start) > MaxAnalyticsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, ErrInvalidAnalyticsRange
+	}
+	return start, end, nil
+}
+
+// conversionRate is applications per unique visitor, as a percentage.
+func conversionRate(applications, visitors int) float64 {
+	if visitors == 0 {
+		return 0
+	}
+	return math.Round(float64(applications)/float64(visitors)*10000) / 100
+}
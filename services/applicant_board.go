@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BoardColumn is one stage of a job's pipeline with the applications in it.
+type BoardColumn struct {
+	Stage      models.ApplicationStatus `json:"stage"`
+	Type       models.StageType         `json:"type,omitempty"`
+	Count      int                      `json:"count"`
+	Applicants []*models.Applicant      `json:"applicants"`
+}
+
+// GetPipelineBoard groups a job's applications by stage, in pipeline order, for a
+// kanban view. Applications in a status outside the pipeline, such as withdrawn
+// ones, get a column of their own after the stages. Each column lists the oldest
+// applications first. Repeat applications replaced by a newer one are left out,
+// as they are from the job's counters.
+func (s *ApplicantService) GetPipelineBoard(jobID primitive.ObjectID) ([]*BoardColumn, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var job models.Job
+	err := s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"_id": jobID})).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.applicantCollection.Find(ctx,
+		bson.M{"jobId": jobID, "duplicateOf": bson.M{"$exists": false}},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var applicants []*models.Applicant
+	if err = cursor.All(ctx, &applicants); err != nil {
+		return nil, err
+	}
+
+	board := []*BoardColumn{}
+	columns := map[models.ApplicationStatus]*BoardColumn{}
+	for _, stage := range jobPipeline(&job) {
+		column := &BoardColumn{Stage: stage.Name, Type: stage.Type, Applicants: []*models.Applicant{}}
+		columns[stage.Name] = column
+		board = append(board, column)
+	}
+	for _, applicant := range applicants {
+		column, ok := columns[applicant.Status]
+		if !ok {
+			column = &BoardColumn{Stage: applicant.Status, Applicants: []*models.Applicant{}}
+			columns[applicant.Status] = column
+			board = append(board, column)
+		}
+		column.Count++
+		column.Applicants = append(column.Applicants, applicant)
+	}
+	return board, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReconcileCounts recomputes the applicant counters of the given jobs, or of every
+// job when none are given, from the applicants collection. Repeat applications
+// replaced by a newer one do not count. It returns how many jobs had wrong counts.
+// Each job gets its own timeout, so large collections are reconciled in full.
+func (s *ApplicantService) ReconcileCounts(jobIDs ...primitive.ObjectID) (int, error) {
+	corrected := 0
+	reconcile := func(jobID primitive.ObjectID) error {
+		var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+		fixed, err := s.reconcileJob(ctx, jobID)
+		if fixed {
+			corrected++
+		}
+		return err
+	}
+	if len(jobIDs) > 0 {
+		for _, jobID := range jobIDs {
+			if err := reconcile(jobID); err != nil {
+				return corrected, err
+			}
+		}
+		return corrected, nil
+	}
+
+	// The cursor lives as long as the walk over all jobs; only the work per job
+	// is bounded.
+	ctx := context.Background()
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := s.jobCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var job struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&job); err != nil {
+			return corrected, err
+		}
+		if err := reconcile(job.ID); err != nil {
+			return corrected, err
+		}
+	}
+	return corrected, cursor.Err()
+}
+
+// reconcileJob counts a job's applications and stores the result in the same
+// transaction, so applications submitted meanwhile are not lost.
+func (s *ApplicantService) reconcileJob(ctx context.Context, jobID primitive.ObjectID) (bool, error) {
+	fixed := false
+	err := withTransaction(ctx, s.client, func(sc mongo.SessionContext) error {
+		fixed = false
+		var job models.Job
+		err := s.jobCollection.FindOne(sc, bson.M{"_id": jobID}).Decode(&job)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrJobNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		cursor, err := s.applicantCollection.Aggregate(sc, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"jobId": jobID, "duplicateOf": bson.M{"$exists": false}}}},
+			{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+		})
+		if err != nil {
+			return err
+		}
+		var rows []struct {
+			Status models.ApplicationStatus `bson:"_id"`
+			Count  int                      `bson:"count"`
+		}
+		if err = cursor.All(sc, &rows); err != nil {
+			return err
+		}
+		total := 0
+		counts := map[models.ApplicationStatus]int{}
+		for _, row := range rows {
+			if row.Status != models.Withdrawn {
+				total += row.Count
+			}
+			counts[row.Status] = row.Count
+		}
+		if total == job.Applicants && sameCounts(counts, job.ApplicantCounts) {
+			return nil
+		}
+
+		_, err = s.jobCollection.UpdateOne(sc,
+			bson.M{"_id": jobID},
+			bson.M{"$set": bson.M{"applicants": total, "applicantCounts": counts}},
+		)
+		fixed = err == nil
+		return err
+	})
+	return fixed, err
+}
+
+// sameCounts compares counters, treating a missing status as zero.
+func sameCounts(a, b map[models.ApplicationStatus]int) bool {
+	for status, count := range a {
+		if b[status] != count {
+			return false
+		}
+	}
+	for status, count := range b {
+		if a[status] != count {
+			return false
+		}
+	}
+	return true
+}
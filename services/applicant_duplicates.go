@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrDuplicateApplication = errors.New("already applied to this job")
+
+// DuplicateApplicationError is returned when a candidate already has an active
+// application to the job; ApplicationID is that application.
+type DuplicateApplicationError struct {
+	ApplicationID primitive.ObjectID
+}
+
+func (e *DuplicateApplicationError) Error() string {
+	return ErrDuplicateApplication.Error() + ": application " + e.ApplicationID.Hex()
+}
+
+func (e *DuplicateApplicationError) Unwrap() error {
+	return ErrDuplicateApplication
+}
+
+// closedApplicationStatuses ended an application before jobs had their own
+// pipelines. A candidate can apply again once theirs is closed; since pipelines,
+// that is on withdrawal or in a rejected stage.
+var closedApplicationStatuses = []models.ApplicationStatus{models.Rejected, models.Withdrawn}
+
+// EnsureIndexes allows one active application per candidate and job, and indexes
+// applications by job and stage and application timelines. Applications saved
+// before the one-application rule get their active flag first, and where a
+// candidate had applied repeatedly only the newest application stays active.
+func (s *ApplicantService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.applicantCollection.UpdateMany(ctx,
+		bson.M{"active": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"active": bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$status", closedApplicationStatuses}}}},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+	if err := s.deactivateRepeats(ctx); err != nil {
+		return err
+	}
+	_, err = s.applicantCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "jobId", Value: 1}, {Key: "applicantId", Value: 1}},
+		Options: options.Index().
+			SetName("one_active_application").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"active": true}),
+	})
+	if err != nil {
+		return err
+	}
+	// Serves the applications of a job by stage, and the stages in use when its
+	// pipeline changes.
+	_, err = s.applicantCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "jobId", Value: 1}, {Key: "status", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.eventCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "applicationId", Value: 1}, {Key: "createdAt", Value: 1}}},
+		{Keys: bson.D{{Key: "jobId", Value: 1}}},
+	})
+	return err
+}
+
+// deactivateRepeats leaves only the newest of a candidate's active applications to
+// the same job active. The older ones point to it and stop counting on the job.
+func (s *ApplicantService) deactivateRepeats(ctx context.Context) error {
+	cursor, err := s.applicantCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"active": true}}},
+		{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          bson.M{"jobId": "$jobId", "applicantId": "$applicantId"},
+			"applications": bson.M{"$push": bson.M{"_id": "$_id", "status": "$status"}},
+			"count":        bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+	var groups []struct {
+		Key struct {
+			JobID primitive.ObjectID `bson:"jobId"`
+		} `bson:"_id"`
+		Applications []struct {
+			ID     primitive.ObjectID       `bson:"_id"`
+			Status models.ApplicationStatus `bson:"status"`
+		} `bson:"applications"`
+	}
+	if err = cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		newest := group.Applications[0].ID
+		var older []primitive.ObjectID
+		removed := map[models.ApplicationStatus]int{}
+		for _, application := range group.Applications[1:] {
+			older = append(older, application.ID)
+			removed[application.Status]++
+		}
+		counters := bson.M{"applicants": -len(older)}
+		for status, count := range removed {
+			counters[statusCounter(status)] = -count
+		}
+		err := withTransaction(ctx, s.client, func(sc mongo.SessionContext) error {
+			_, err := s.applicantCollection.UpdateMany(sc,
+				bson.M{"_id": bson.M{"$in": older}},
+				bson.M{"$set": bson.M{"active": false, "duplicateOf": newest}},
+			)
+			if err != nil {
+				return err
+			}
+			_, err = s.jobCollection.UpdateOne(sc, bson.M{"_id": group.Key.JobID}, bson.M{"$inc": counters})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activeApplication finds the candidate's active application to the job, other
+// than except. It returns nil when there is none.
+func (s *ApplicantService) activeApplication(ctx context.Context, jobID, applicantID, except primitive.ObjectID) (*models.Applicant, error) {
+	var applicant models.Applicant
+	err := s.applicantCollection.FindOne(ctx, bson.M{
+		"jobId":       jobID,
+		"applicantId": applicantID,
+		"active":      true,
+		"_id":         bson.M{"$ne": except},
+	}).Decode(&applicant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &applicant, nil
+}
+
+// duplicateError turns a write rejected by the unique index into a
+// DuplicateApplicationError naming the application already there.
+func (s *ApplicantService) duplicateError(ctx context.Context, err error, jobID, applicantID, except primitive.ObjectID) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	existing, findErr := s.activeApplication(ctx, jobID, applicantID, except)
+	if findErr != nil || existing == nil {
+		return ErrDuplicateApplication
+	}
+	return &DuplicateApplicationError{ApplicationID: existing.ID}
+}
@@ -3,29 +3,59 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"jobsy-api/models"
+	"jobsy-api/storage"
 	"net/mail"
 	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var (
+	ErrInvalidApplication = errors.New("invalid application")
+	ErrApplicantNotFound  = errors.New("application not found")
+)
+
+// LocationBreakdown counts a job's applications for one of its locations.
+type LocationBreakdown struct {
+	Location string                           `json:"location"`
+	Total    int                              `json:"total"`
+	ByStatus map[models.ApplicationStatus]int `json:"byStatus"`
+}
+
 type ApplicantService struct {
+	client              *mongo.Client
 	applicantCollection *mongo.Collection
 	jobCollection       *mongo.Collection
+	userCollection      *mongo.Collection
+	eventCollection     *mongo.Collection
+	notifier            Notifier
+	storage             storage.Storage
+	maxResumeBytes      int64
+	resumeLinkTTL       time.Duration
+	tagNormalizer       TagNormalizer
 }
 
-func NewApplicantService(db *mongo.Client, applicantCollectionName, jobCollectionName string) *ApplicantService {
+func NewApplicantService(db *mongo.Client, applicantCollectionName, jobCollectionName, userCollectionName, eventCollectionName string, notifier Notifier) *ApplicantService {
 	return &ApplicantService{
+		client:              db,
 		applicantCollection: db.Database("jobsy-api").Collection(applicantCollectionName),
 		jobCollection:       db.Database("jobsy-api").Collection(jobCollectionName),
+		userCollection:      db.Database("jobsy-api").Collection(userCollectionName),
+		eventCollection:     db.Database("jobsy-api").Collection(eventCollectionName),
+		notifier:            notifier,
 	}
 }
 
-func (s *ApplicantService) CreateApplicant(applicant *models.Applicant, userID primitive.ObjectID) error {
+// CreateApplicant saves an application, with the uploaded resume if there is one.
+func (s *ApplicantService) CreateApplicant(applicant *models.Applicant, userID primitive.ObjectID, resume *ResumeUpload) error {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	applicant.ID = primitive.NewObjectID()
@@ -33,19 +63,105 @@ func (s *ApplicantService) CreateApplicant(applicant *models.Applicant, userID p
 	applicant.CreatedAt = time.Now()
 	applicant.UpdatedAt = time.Now()
 	applicant.Status = models.Pending
+	applicant.Active = true
+	applicant.ResumeFile = nil
 
+	if resume != nil {
+		file, err := s.resumeFile(applicant.ID, resume)
+		if err != nil {
+			return err
+		}
+		applicant.ResumeFile = file
+	}
 	if err := validateApplicant(applicant); err != nil {
 		return err
 	}
-
-	_, err := s.applicantCollection.InsertOne(ctx, applicant)
+	var job models.Job
+	err := s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"_id": applicant.JobID})).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrJobNotFound
+	}
 	if err != nil {
 		return err
 	}
-	filter := bson.M{"_id": applicant.JobID}
-	update := bson.M{"$inc": bson.M{"applicants": 1}}
-	_, err = s.jobCollection.UpdateOne(context.Background(), filter, update)
-	return err
+	if slices.Contains(hiddenJobStatuses, job.Status) {
+		return ErrJobNotFound
+	}
+	if job.Status != models.Open {
+		return fmt.Errorf("%w: the job is not accepting applications", ErrInvalidApplication)
+	}
+	if err := applicationLocation(applicant, &job); err != nil {
+		return err
+	}
+	existing, err := s.activeApplication(ctx, applicant.JobID, userID, applicant.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return &DuplicateApplicationError{ApplicationID: existing.ID}
+	}
+
+	if applicant.ResumeFile != nil {
+		if err := s.storeResume(ctx, applicant.ResumeFile, resume); err != nil {
+			return err
+		}
+	}
+	// The application and the job's counters are written together, so a failure
+	// leaves neither behind.
+	err = withTransaction(ctx, s.client, func(sc mongo.SessionContext) error {
+		if _, err := s.applicantCollection.InsertOne(sc, applicant); err != nil {
+			return err
+		}
+		result, err := s.jobCollection.UpdateOne(sc,
+			excludeDeleted(bson.M{"_id": applicant.JobID, "status": models.Open}),
+			bson.M{"$inc": bson.M{"applicants": 1, statusCounter(applicant.Status): 1}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrJobNotFound
+		}
+		return s.recordEvent(sc, &models.ApplicationEvent{
+			ApplicationID: applicant.ID,
+			JobID:         applicant.JobID,
+			ActorID:       userID,
+			ActorRole:     models.RoleApplicant,
+			To:            applicant.Status,
+			StageType:     models.StageScreening,
+			CreatedAt:     applicant.CreatedAt,
+		})
+	})
+	if err != nil {
+		s.deleteResume(ctx, applicant.ResumeFile)
+		// The unique index catches a second application sent at the same time.
+		return s.duplicateError(ctx, err, applicant.JobID, userID, applicant.ID)
+	}
+	return nil
+}
+
+// statusCounter is the field of a job counting its applications in a status.
+func statusCounter(status models.ApplicationStatus) string {
+	return "applicantCounts." + string(status)
+}
+
+// applicationLocation stores the job location the candidate applied for under its
+// current label. Jobs with a single location need no choice.
+func applicationLocation(applicant *models.Applicant, job *models.Job) error {
+	locations := jobLocations(job)
+	if strings.TrimSpace(applicant.Location) == "" {
+		applicant.Location = ""
+		if len(locations) == 1 {
+			applicant.Location = locations[0].Label()
+		}
+		return nil
+	}
+	label, ok := matchJobLocation(job, applicant.Location)
+	if !ok {
+		return fmt.Errorf("%w: %q is not one of the job's locations", ErrInvalidApplication, applicant.Location)
+	}
+	applicant.Location = label
+	return nil
 }
 
 func validateApplicant(applicant *models.Applicant) error {
@@ -129,8 +245,8 @@ func validateApplicant(applicant *models.Applicant) error {
 		return errors.New("summary is required")
 	}
 
-	// Validate Resume
-	if applicant.Resume == "" {
+	// Validate Resume (a link or an uploaded file)
+	if applicant.Resume == "" && applicant.ResumeFile == nil {
 		return errors.New("resume is required")
 	}
 
@@ -169,45 +285,337 @@ func (s *ApplicantService) GetApplicantsByJobID(jobID string) ([]*models.Applica
 	return applicants, nil
 }
 
-func (s *ApplicantService) GetApplicantByID(id string) (*models.Applicant, error) {
+// GetLocationBreakdown counts the job's applications per location and status. Every
+// current location is listed, even without applications. Applications for a
+// location since removed keep their own row, and those that named no location are
+// grouped under an empty one.
+func (s *ApplicantService) GetLocationBreakdown(jobID primitive.ObjectID) ([]*LocationBreakdown, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
-	objectID, err := primitive.ObjectIDFromHex(id)
+	var job models.Job
+	err := s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"_id": jobID})).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.applicantCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"jobId": jobID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"location": "$location", "status": "$status"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.location", Value: 1}, {Key: "_id.status", Value: 1}}}},
+	})
 	if err != nil {
 		return nil, err
 	}
+	var rows []struct {
+		ID struct {
+			Location string                   `bson:"location"`
+			Status   models.ApplicationStatus `bson:"status"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	breakdown := []*LocationBreakdown{}
+	byLocation := map[string]*LocationBreakdown{}
+	entry := func(location string) *LocationBreakdown {
+		if existing, ok := byLocation[location]; ok {
+			return existing
+		}
+		created := &LocationBreakdown{Location: location, ByStatus: map[models.ApplicationStatus]int{}}
+		byLocation[location] = created
+		breakdown = append(breakdown, created)
+		return created
+	}
+	for _, location := range jobLocations(&job) {
+		entry(location.Label())
+	}
+	for _, row := range rows {
+		location := entry(row.ID.Location)
+		location.Total += row.Count
+		location.ByStatus[row.ID.Status] += row.Count
+	}
+	return breakdown, nil
+}
+
+// GetApplicantByID returns an application to its candidate or to the company that
+// owns the job. A zero viewer is an admin, who may read any.
+func (s *ApplicantService) GetApplicantByID(id string, viewer primitive.ObjectID) (*models.Applicant, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrApplicantNotFound
+	}
 
 	var applicant models.Applicant
 	err = s.applicantCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&applicant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrApplicantNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	if viewer.IsZero() || applicant.ApplicantID == viewer {
+		return &applicant, nil
+	}
 
+	var job models.Job
+	err = s.jobCollection.FindOne(ctx, bson.M{"_id": applicant.JobID}, options.FindOne().SetProjection(bson.M{"company": 1})).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.Company != viewer {
+		return nil, ErrNotJobOwner
+	}
 	return &applicant, nil
 }
 
-func (s *ApplicantService) UpdateApplicantStatus(id string, status models.ApplicationStatus) (*models.Applicant, error) {
+// UpdateApplicantStatus moves an application to another stage of its job's
+// pipeline and adds the move, with the note, to its timeline. Only the company
+// that owns the job or an admin may move it.
+func (s *ApplicantService) UpdateApplicantStatus(id string, status models.ApplicationStatus, note string, actorID primitive.ObjectID, actorRole string) (*models.Applicant, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, ErrApplicantNotFound
 	}
 
-	_, err = s.applicantCollection.UpdateOne(
-		ctx,
-		bson.M{"_id": objectID},
-		bson.M{"$set": bson.M{"status": status}},
-	)
+	var applicant models.Applicant
+	var stage models.PipelineStage
+	now := time.Now()
+	err = withTransaction(ctx, s.client, func(sc mongo.SessionContext) error {
+		err := s.applicantCollection.FindOne(sc, bson.M{"_id": objectID}).Decode(&applicant)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrApplicantNotFound
+		}
+		if err != nil {
+			return err
+		}
+		var job models.Job
+		err = s.jobCollection.FindOne(sc, bson.M{"_id": applicant.JobID}).Decode(&job)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrJobNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if actorRole != models.RoleAdmin && job.Company != actorID {
+			return ErrNotJobOwner
+		}
+		if applicant.Status == models.Withdrawn {
+			return fmt.Errorf("%w: the candidate withdrew the application", ErrInvalidApplication)
+		}
+		if applicant.DuplicateOf != nil {
+			return fmt.Errorf("%w: the candidate applied again, see application %s", ErrInvalidApplication, applicant.DuplicateOf.Hex())
+		}
+		stage, err = checkStageMove(jobPipeline(&job), applicant.Status, status)
+		if err != nil || applicant.Status == status {
+			return err
+		}
+
+		_, err = s.applicantCollection.UpdateOne(sc,
+			bson.M{"_id": objectID},
+			bson.M{"$set": bson.M{"status": status, "active": stage.Type != models.StageRejected, "updatedAt": now}},
+		)
+		if err != nil {
+			return err
+		}
+		// Deleted jobs keep counting too, so they are right if restored.
+		_, err = s.jobCollection.UpdateOne(sc,
+			bson.M{"_id": applicant.JobID},
+			bson.M{"$inc": bson.M{statusCounter(applicant.Status): -1, statusCounter(status): 1}},
+		)
+		if err != nil {
+			return err
+		}
+		return s.recordEvent(sc, &models.ApplicationEvent{
+			ApplicationID: applicant.ID,
+			JobID:         applicant.JobID,
+			ActorID:       actorID,
+			ActorRole:     actorRole,
+			From:          applicant.Status,
+			To:            status,
+			StageType:     stage.Type,
+			Note:          strings.TrimSpace(note),
+			CreatedAt:     now,
+		})
+	})
+	if err != nil {
+		// Reopening a rejected application the candidate has since replaced.
+		return nil, s.duplicateError(ctx, err, applicant.JobID, applicant.ApplicantID, objectID)
+	}
+
+	if applicant.Status != status {
+		applicant.Status = status
+		applicant.Active = stage.Type != models.StageRejected
+		applicant.UpdatedAt = now
+	}
+	return &applicant, nil
+}
+
+// UpdateApplication lets a candidate change their own application while it is
+// still pending, before the recruiter has looked at it. Fields left empty in
+// changes are kept, and a new resume replaces the uploaded one. The job and the
+// status cannot be changed this way.
+func (s *ApplicantService) UpdateApplication(id, owner primitive.ObjectID, changes *models.Applicant, resume *ResumeUpload) (*models.Applicant, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var applicant models.Applicant
+	err := s.applicantCollection.FindOne(ctx, bson.M{"_id": id, "applicantId": owner}).Decode(&applicant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrApplicantNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	if applicant.Status != models.Pending {
+		return nil, fmt.Errorf("%w: only pending applications can be edited", ErrInvalidApplication)
+	}
 
-	var updatedApplicant models.Applicant
-	err = s.applicantCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&updatedApplicant)
+	previousResume := applicant.ResumeFile
+	mergeApplication(&applicant, changes)
+	if resume != nil {
+		file, err := s.resumeFile(applicant.ID, resume)
+		if err != nil {
+			return nil, err
+		}
+		applicant.ResumeFile = file
+	}
+	if err := validateApplicant(&applicant); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidApplication, err)
+	}
+	if changes.Location != "" {
+		var job models.Job
+		err := s.jobCollection.FindOne(ctx, bson.M{"_id": applicant.JobID}).Decode(&job)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrJobNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := applicationLocation(&applicant, &job); err != nil {
+			return nil, err
+		}
+	}
+
+	if resume != nil {
+		if err := s.storeResume(ctx, applicant.ResumeFile, resume); err != nil {
+			return nil, err
+		}
+	}
+	applicant.UpdatedAt = time.Now()
+	result, err := s.applicantCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "applicantId": owner, "status": models.Pending},
+		bson.M{"$set": bson.M{
+			"firstname":           applicant.Firstname,
+			"lastname":            applicant.Lastname,
+			"email":               applicant.Email,
+			"phone":               applicant.Phone,
+			"linkedinProfile":     applicant.LinkedinProfile,
+			"websiteUrl":          applicant.WebsiteURL,
+			"experience":          applicant.Experience,
+			"currentJobTitle":     applicant.CurrentJobTitle,
+			"currentEmployerName": applicant.CurrentEmployerName,
+			"desiredSalary":       applicant.DesiredSalary,
+			"availabilityDate":    applicant.AvailabilityDate,
+			"summary":             applicant.Summary,
+			"resume":              applicant.Resume,
+			"resumeFile":          applicant.ResumeFile,
+			"location":            applicant.Location,
+			"updatedAt":           applicant.UpdatedAt,
+		}},
+	)
+	if err == nil && result.MatchedCount == 0 {
+		err = fmt.Errorf("%w: only pending applications can be edited", ErrInvalidApplication)
+	}
 	if err != nil {
+		if resume != nil {
+			s.deleteResume(ctx, applicant.ResumeFile)
+		}
 		return nil, err
 	}
+	if resume != nil {
+		s.deleteResume(ctx, previousResume)
+	}
+	return &applicant, nil
+}
+
+// mergeApplication copies the details a candidate filled in over their
+// application.
+func mergeApplication(applicant, changes *models.Applicant) {
+	fields := []struct {
+		target *string
+		value  string
+	}{
+		{&applicant.Firstname, changes.Firstname},
+		{&applicant.Lastname, changes.Lastname},
+		{&applicant.Email, changes.Email},
+		{&applicant.Phone, changes.Phone},
+		{&applicant.LinkedinProfile, changes.LinkedinProfile},
+		{&applicant.WebsiteURL, changes.WebsiteURL},
+		{&applicant.Experience, changes.Experience},
+		{&applicant.CurrentJobTitle, changes.CurrentJobTitle},
+		{&applicant.CurrentEmployerName, changes.CurrentEmployerName},
+		{&applicant.DesiredSalary, changes.DesiredSalary},
+		{&applicant.AvailabilityDate, changes.AvailabilityDate},
+		{&applicant.Summary, changes.Summary},
+		{&applicant.Resume, changes.Resume},
+		{&applicant.Location, changes.Location},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			*field.target = field.value
+		}
+	}
+}
+
+// DeleteApplicant removes an application and takes it off the job's counters. A
+// non-zero owner restricts it to that candidate's own applications.
+func (s *ApplicantService) DeleteApplicant(id, owner primitive.ObjectID) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	filter := bson.M{"_id": id}
+	if !owner.IsZero() {
+		filter["applicantId"] = owner
+	}
 
-	return &updatedApplicant, nil
+	var applicant models.Applicant
+	err := withTransaction(ctx, s.client, func(sc mongo.SessionContext) error {
+		err := s.applicantCollection.FindOneAndDelete(sc, filter).Decode(&applicant)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrApplicantNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if applicant.DuplicateOf == nil {
+			counters := bson.M{statusCounter(applicant.Status): -1}
+			if applicant.Status != models.Withdrawn {
+				counters["applicants"] = -1
+			}
+			_, err = s.jobCollection.UpdateOne(sc, bson.M{"_id": applicant.JobID}, bson.M{"$inc": counters})
+			if err != nil {
+				return err
+			}
+		}
+		_, err = s.eventCollection.DeleteMany(sc, bson.M{"applicationId": applicant.ID})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.deleteResume(ctx, applicant.ResumeFile)
+	return nil
 }
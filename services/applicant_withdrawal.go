@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsy-api/models"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// WithdrawApplication lets a candidate take back their own application while it
+// is still open. The job stops counting it as an applicant, and the recruiter is
+// told.
+func (s *ApplicantService) WithdrawApplication(id, owner primitive.ObjectID, reason string) (*models.Applicant, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var applicant models.Applicant
+	now := time.Now()
+	reason = strings.TrimSpace(reason)
+	err := withTransaction(ctx, s.client, func(sc mongo.SessionContext) error {
+		err := s.applicantCollection.FindOne(sc, bson.M{"_id": id, "applicantId": owner}).Decode(&applicant)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrApplicantNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !applicant.Active {
+			return fmt.Errorf("%w: the application is already %s", ErrInvalidApplication, strings.ToLower(string(applicant.Status)))
+		}
+
+		_, err = s.applicantCollection.UpdateOne(sc,
+			bson.M{"_id": id, "status": applicant.Status},
+			bson.M{"$set": bson.M{"status": models.Withdrawn, "active": false, "updatedAt": now}},
+		)
+		if err != nil {
+			return err
+		}
+		_, err = s.jobCollection.UpdateOne(sc,
+			bson.M{"_id": applicant.JobID},
+			bson.M{"$inc": bson.M{
+				"applicants":                    -1,
+				statusCounter(applicant.Status): -1,
+				statusCounter(models.Withdrawn): 1,
+			}},
+		)
+		if err != nil {
+			return err
+		}
+		return s.recordEvent(sc, &models.ApplicationEvent{
+			ApplicationID: applicant.ID,
+			JobID:         applicant.JobID,
+			ActorID:       owner,
+			ActorRole:     models.RoleApplicant,
+			From:          applicant.Status,
+			To:            models.Withdrawn,
+			Note:          reason,
+			CreatedAt:     now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	previous := applicant.Status
+	applicant.Status = models.Withdrawn
+	applicant.Active = false
+	applicant.UpdatedAt = now
+	s.notifyRecruiter(&applicant, previous, reason)
+	return &applicant, nil
+}
+
+// notifyRecruiter tells the job's owner about a withdrawal. It never fails the
+// withdrawal; a lost notification is only logged.
+func (s *ApplicantService) notifyRecruiter(applicant *models.Applicant, previous models.ApplicationStatus, reason string) {
+	if s.notifier == nil {
+		return
+	}
+	var ctx, cancel = context.WithTimeout(context.Background(), alertNotifyTimeout)
+	defer cancel()
+	var job models.Job
+	if err := s.jobCollection.FindOne(ctx, bson.M{"_id": applicant.JobID}).Decode(&job); err != nil {
+		log.Printf("applicants: no job %s for withdrawn application %s: %v", applicant.JobID.Hex(), applicant.ID.Hex(), err)
+		return
+	}
+	var owner models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": job.Company}).Decode(&owner); err != nil {
+		log.Printf("applicants: no owner for job %s: %v", job.ID.Hex(), err)
+		return
+	}
+
+	name := strings.TrimSpace(applicant.Firstname + " " + applicant.Lastname)
+	body := fmt.Sprintf("%s withdrew their application to %q. It was %s.", name, job.Title, strings.ToLower(string(previous)))
+	if reason != "" {
+		body += "\nReason: " + reason
+	}
+	err := s.notifier.Notify(ctx, Notification{UserID: owner.ID, To: owner.Email, Subject: "Application withdrawn: " + job.Title, Body: body})
+	if err != nil {
+		log.Printf("applicants: notifying %s about application %s: %v", owner.ID.Hex(), applicant.ID.Hex(), err)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CandidateEvent is a step of an application as the candidate sees it: what
+// happened and when, without the company's stage names, notes or who made the
+// change.
+type CandidateEvent struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// candidateStatuses are the stage types as shown to candidates.
+var candidateStatuses = map[models.StageType]string{
+	models.StageScreening: "In review",
+	models.StageInterview: "Interviewing",
+	models.StageOffer:     "Offer",
+	models.StageHired:     "Hired",
+	models.StageRejected:  "Not selected",
+}
+
+// recordEvent adds an event to the application's timeline. Pass the session
+// context, so the event is written with the change it records.
+func (s *ApplicantService) recordEvent(ctx context.Context, event *models.ApplicationEvent) error {
+	event.ID = primitive.NewObjectID()
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	_, err := s.eventCollection.InsertOne(ctx, event)
+	return err
+}
+
+// GetTimeline returns every status change of an application, oldest first, for
+// the company that owns the job. A zero owner is an admin, who may see any.
+func (s *ApplicantService) GetTimeline(id, owner primitive.ObjectID) ([]*models.ApplicationEvent, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var applicant models.Applicant
+	err := s.applicantCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&applicant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrApplicantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !owner.IsZero() {
+		var job models.Job
+		opts := options.FindOne().SetProjection(bson.M{"company": 1})
+		err := s.jobCollection.FindOne(ctx, bson.M{"_id": applicant.JobID}, opts).Decode(&job)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrJobNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if job.Company != owner {
+			return nil, ErrNotJobOwner
+		}
+	}
+	return s.applicationEvents(ctx, &applicant)
+}
+
+// GetCandidateTimeline returns the candidate's view of their own application's
+// timeline. Changes that look the same to the candidate, such as moving between
+// two screening stages, are shown once.
+func (s *ApplicantService) GetCandidateTimeline(id, applicantID primitive.ObjectID) ([]*CandidateEvent, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var applicant models.Applicant
+	err := s.applicantCollection.FindOne(ctx, bson.M{"_id": id, "applicantId": applicantID}).Decode(&applicant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrApplicantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.applicationEvents(ctx, &applicant)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := []*CandidateEvent{}
+	for _, event := range events {
+		status := candidateStatuses[event.StageType]
+		switch {
+		case event.From == "":
+			status = "Application submitted"
+		case event.To == models.Withdrawn:
+			status = "Withdrawn"
+		case status == "":
+			continue
+		}
+		if len(timeline) > 0 && timeline[len(timeline)-1].Status == status {
+			continue
+		}
+		timeline = append(timeline, &CandidateEvent{Status: status, At: event.CreatedAt})
+	}
+	return timeline, nil
+}
+
+// applicationEvents loads the timeline of an application. Applications submitted
+// before timelines were recorded get their submission from the application.
+func (s *ApplicantService) applicationEvents(ctx context.Context, applicant *models.Applicant) ([]*models.ApplicationEvent, error) {
+	cursor, err := s.eventCollection.Find(ctx,
+		bson.M{"applicationId": applicant.ID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	events := []*models.ApplicationEvent{}
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 || events[0].From != "" {
+		submitted := &models.ApplicationEvent{
+			ApplicationID: applicant.ID,
+			JobID:         applicant.JobID,
+			ActorID:       applicant.ApplicantID,
+			ActorRole:     models.RoleApplicant,
+			To:            models.Pending,
+			StageType:     models.StageScreening,
+			CreatedAt:     applicant.CreatedAt,
+		}
+		events = append([]*models.ApplicationEvent{submitted}, events...)
+	}
+	return events, nil
+}
+
+// DeleteEventsForJobs removes the timelines of applications to jobs that are being
+// purged. It is registered as a JobService purge hook.
+func (s *ApplicantService) DeleteEventsForJobs(ctx context.Context, jobIDs []primitive.ObjectID) error {
+	_, err := s.eventCollection.DeleteMany(ctx, bson.M{"jobId": bson.M{"$in": jobIDs}})
+	return err
+}
@@ -37,6 +37,21 @@ func (s *AuthService) CreateUser(user *models.User) (*mongo.InsertOneResult, err
 	return s.userCollection.InsertOne(ctx, user)
 }
 
+// SetUserRole changes the role of the user with the given email.
+func (s *AuthService) SetUserRole(email, role string) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"role": role, "updatedAt": time.Now()}}
+	result, err := s.userCollection.UpdateOne(ctx, bson.M{"email": email}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
 func (s *AuthService) UpdateUserToken(userID primitive.ObjectID, token string) error {
 	filter := bson.M{"_id": userID}
 	update := bson.M{"$set": bson.M{"token": token}}
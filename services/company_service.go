@@ -2,7 +2,10 @@ package services
 
 import (
 	"context"
+	"jobsy-api/markup"
 	"jobsy-api/models"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,23 +15,59 @@ import (
 
 type CompanyService struct {
 	companyCollection *mongo.Collection
+	geocoder          Geocoder
 }
 
 func NewCompanyService(db *mongo.Client, collection string) *CompanyService {
 	return &CompanyService{
 		companyCollection: db.Database("jobsy-api").Collection(collection),
+		geocoder:          NewOfflineGeocoder(),
 	}
 }
 
+func (s *CompanyService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.companyCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "geo", Value: "2dsphere"}}},
+	})
+	return err
+}
+
 func (s *CompanyService) CreateCompany(company *models.Company) (*mongo.InsertOneResult, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	company.ID = primitive.NewObjectID()
 	company.CreatedAt = time.Now()
 	company.UpdatedAt = time.Now()
+	// Only admins verify companies, and pipelines are checked by SetDefaultPipeline.
+	company.Verified = false
+	company.Pipeline = nil
+	company.DescriptionHTML = markup.Render(company.Description)
+	if company.Geo != nil && !company.Geo.Valid() {
+		company.Geo = nil
+	}
+	if company.Geo == nil {
+		if point, ok := s.geocoder.Geocode(companyAddress(company)); ok {
+			company.Geo = point
+		}
+	}
 	return s.companyCollection.InsertOne(ctx, company)
 }
 
+func companyAddress(company *models.Company) string {
+	parts := []string{}
+	if company.Pincode != 0 {
+		parts = append(parts, strconv.Itoa(company.Pincode))
+	}
+	for _, part := range []string{company.City, company.State, company.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (s *CompanyService) GetCompanyByID(id primitive.ObjectID) (*models.Company, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
@@ -37,6 +76,28 @@ func (s *CompanyService) GetCompanyByID(id primitive.ObjectID) (*models.Company,
 	return &company, err
 }
 
+// GetCompaniesByUserIDs returns the companies owned by the given users keyed by user ID.
+// Jobs reference the owning user, so this is how a job's company profile is resolved.
+func (s *CompanyService) GetCompaniesByUserIDs(userIDs []primitive.ObjectID) (map[primitive.ObjectID]*models.Company, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	cursor, err := s.companyCollection.Find(ctx, bson.M{"userId": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+
+	var companies []*models.Company
+	if err = cursor.All(ctx, &companies); err != nil {
+		return nil, err
+	}
+
+	byUser := make(map[primitive.ObjectID]*models.Company, len(companies))
+	for _, company := range companies {
+		byUser[company.UserId] = company
+	}
+	return byUser, nil
+}
+
 // func (s *CompanyService) UpdateCompany(id string, company *models.Company) (*models.Company, error) {
 // 	objectID, _ := primitive.ObjectIDFromHex(id)
 
@@ -0,0 +1,125 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"jobsy-api/models"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Geocoder resolves a free-text location such as "Pune, Maharashtra" or a postcode
+// to coordinates.
+type Geocoder interface {
+	Geocode(location string) (*models.GeoPoint, bool)
+}
+
+//go:embed geodata/places.csv
+var placesCSV string
+
+// OfflineGeocoder looks locations up in the bundled city/postcode dataset, so it
+// works without network access. Unknown places simply return false.
+type OfflineGeocoder struct {
+	cities    map[string]*models.GeoPoint
+	postcodes map[string]*models.GeoPoint
+}
+
+var (
+	offlineGeocoder     *OfflineGeocoder
+	offlineGeocoderOnce sync.Once
+	postcodePattern     = regexp.MustCompile(`\b\d{5,6}\b`)
+)
+
+// NewOfflineGeocoder returns the shared geocoder built from the bundled dataset.
+func NewOfflineGeocoder() *OfflineGeocoder {
+	offlineGeocoderOnce.Do(func() {
+		geocoder, err := parsePlaces(placesCSV)
+		if err != nil {
+			log.Fatal("Failed to load bundled places dataset:", err)
+		}
+		offlineGeocoder = geocoder
+	})
+	return offlineGeocoder
+}
+
+func parsePlaces(data string) (*OfflineGeocoder, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	geocoder := &OfflineGeocoder{
+		cities:    map[string]*models.GeoPoint{},
+		postcodes: map[string]*models.GeoPoint{},
+	}
+	// kind,name,aliases,region,country,postcode,lat,lng
+	for _, record := range records[1:] {
+		lat, err := strconv.ParseFloat(record[6], 64)
+		if err != nil {
+			return nil, err
+		}
+		lng, err := strconv.ParseFloat(record[7], 64)
+		if err != nil {
+			return nil, err
+		}
+		point := models.NewGeoPoint(lng, lat)
+
+		switch record[0] {
+		case "postcode":
+			geocoder.postcodes[record[5]] = point
+		case "city":
+			names := append([]string{record[1]}, strings.Split(record[2], "|")...)
+			for _, name := range names {
+				if key := placeKey(name); key != "" {
+					geocoder.cities[key] = point
+				}
+			}
+		}
+	}
+	return geocoder, nil
+}
+
+// Geocode tries postcodes first, then the whole string, then each comma separated
+// part in order, so "Baner, Pune 411045, India" still resolves to Pune.
+func (g *OfflineGeocoder) Geocode(location string) (*models.GeoPoint, bool) {
+	for _, code := range postcodePattern.FindAllString(location, -1) {
+		if point, ok := g.postcodes[code]; ok {
+			return models.NewGeoPoint(point.Lng(), point.Lat()), true
+		}
+	}
+
+	if point, ok := g.cities[placeKey(location)]; ok {
+		return models.NewGeoPoint(point.Lng(), point.Lat()), true
+	}
+	for _, part := range strings.FieldsFunc(location, func(r rune) bool {
+		return r == ',' || r == '/' || r == '(' || r == ')' || r == ';'
+	}) {
+		part = postcodePattern.ReplaceAllString(part, "")
+		if point, ok := g.cities[placeKey(part)]; ok {
+			return models.NewGeoPoint(point.Lng(), point.Lat()), true
+		}
+	}
+	return nil, false
+}
+
+// placeKey lowercases and strips accents and punctuation so "München" and
+// "munchen" share a key.
+func placeKey(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == '-':
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
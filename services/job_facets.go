@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"jobsy-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	maxLocationFacets = 10
+	maxCompanyFacets  = 10
+	maxTagFacets      = 20
+	// MaxFacetedJobs caps the jobs returned with facets, since the whole $facet
+	// result must fit in a single 16MB document. Total still counts every match.
+	MaxFacetedJobs = 1000
+)
+
+// salaryBucketBoundaries are the lower bounds of the salary facet buckets. Amounts
+// are compared as numbers regardless of currency; the last bucket is open ended.
+var salaryBucketBoundaries = []float64{0, 25000, 50000, 75000, 100000, 150000, 200000}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int    `json:"count"`
+}
+
+type JobFacets struct {
+	JobType  []FacetCount `json:"jobType"`
+	WorkType []FacetCount `json:"workType"`
+	Location []FacetCount `json:"location"`
+	Company  []FacetCount `json:"company"`
+	Tags     []FacetCount `json:"tags"`
+	Salary   []FacetCount `json:"salary"`
+}
+
+type JobSearchResult struct {
+	Total  int           `json:"total"`
+	Jobs   []*JobListing `json:"jobs"`
+	Facets JobFacets     `json:"facets"`
+}
+
+// SearchJobs runs the listing query and computes facet counts over the same
+// matches in one aggregation, so the counts always agree with the results.
+func (s *JobService) SearchJobs(query *models.JobQuery) (*JobSearchResult, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	pipeline, err := s.jobListPipeline(query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	countBy := func(field string, limit int) bson.A {
+		stages := bson.A{
+			bson.M{"$match": bson.M{field: bson.M{"$nin": bson.A{nil, ""}}}},
+			bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		}
+		if limit > 0 {
+			stages = append(stages, bson.M{"$limit": limit})
+		}
+		return stages
+	}
+	amount := func(field string) bson.M {
+		withoutCommas := bson.M{"$replaceAll": bson.M{"input": field, "find": ",", "replacement": ""}}
+		return bson.M{"$convert": bson.M{"input": withoutCommas, "to": "double", "onError": nil, "onNull": nil}}
+	}
+	boundaries := bson.A{}
+	for _, boundary := range salaryBucketBoundaries {
+		boundaries = append(boundaries, boundary)
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: bson.M{
+		"jobs":     bson.A{bson.M{"$limit": MaxFacetedJobs}},
+		"total":    bson.A{bson.M{"$count": "count"}},
+		"jobType":  countBy("jobType", 0),
+		"workType": countBy("workType", 0),
+		"location": append(bson.A{bson.M{"$unwind": "$locations"}}, countBy("locations.city", maxLocationFacets)...),
+		"company": append(countBy("company", maxCompanyFacets),
+			bson.M{"$lookup": bson.M{
+				"from":         s.companyCollection.Name(),
+				"localField":   "_id",
+				"foreignField": "userId",
+				"as":           "profile",
+			}},
+			bson.M{"$project": bson.M{"count": 1, "label": bson.M{"$first": "$profile.name"}}},
+		),
+		"tags": bson.A{
+			bson.M{"$unwind": "$tags"},
+			bson.M{"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": maxTagFacets},
+		},
+		"salary": bson.A{
+			// Salary amounts are stored as strings; thousands separators are dropped
+			// and anything that is not a number is ignored, like parseSalaryAmount.
+			bson.M{"$project": bson.M{"amount": bson.M{"$avg": bson.A{
+				amount("$salary.min"),
+				amount("$salary.max"),
+			}}}},
+			bson.M{"$match": bson.M{"amount": bson.M{"$gte": 0}}},
+			bson.M{"$bucket": bson.M{
+				"groupBy":    "$amount",
+				"boundaries": append(boundaries, float64(1<<53)),
+				"output":     bson.M{"count": bson.M{"$sum": 1}},
+			}},
+		},
+	}}})
+
+	cursor, err := s.jobCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Jobs  []*JobListing `bson:"jobs"`
+		Total []struct {
+			Count int `bson:"count"`
+		} `bson:"total"`
+		JobType  []facetRow `bson:"jobType"`
+		WorkType []facetRow `bson:"workType"`
+		Location []facetRow `bson:"location"`
+		Company  []facetRow `bson:"company"`
+		Tags     []facetRow `bson:"tags"`
+		Salary   []struct {
+			Lower float64 `bson:"_id"`
+			Count int     `bson:"count"`
+		} `bson:"salary"`
+	}
+	if err = cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	result := &JobSearchResult{Jobs: []*JobListing{}}
+	if len(rows) == 0 {
+		return result, nil
+	}
+	row := rows[0]
+	if row.Jobs != nil {
+		result.Jobs = row.Jobs
+	}
+	for _, listing := range result.Jobs {
+		s.canonical(&listing.Job)
+	}
+	if len(row.Total) > 0 {
+		result.Total = row.Total[0].Count
+	}
+	result.Facets = JobFacets{
+		JobType:  facetCounts(row.JobType),
+		WorkType: facetCounts(row.WorkType),
+		Location: facetCounts(row.Location),
+		Company:  facetCounts(row.Company),
+		Tags:     facetCounts(row.Tags),
+		Salary:   []FacetCount{},
+	}
+	for _, bucket := range row.Salary {
+		result.Facets.Salary = append(result.Facets.Salary, FacetCount{Value: salaryBucketLabel(bucket.Lower), Count: bucket.Count})
+	}
+	return result, nil
+}
+
+type facetRow struct {
+	Value interface{} `bson:"_id"`
+	Label string      `bson:"label"`
+	Count int         `bson:"count"`
+}
+
+func facetCounts(rows []facetRow) []FacetCount {
+	counts := make([]FacetCount, 0, len(rows))
+	for _, row := range rows {
+		value := fmt.Sprint(row.Value)
+		if id, ok := row.Value.(primitive.ObjectID); ok {
+			value = id.Hex()
+		}
+		counts = append(counts, FacetCount{Value: value, Label: row.Label, Count: row.Count})
+	}
+	return counts
+}
+
+// salaryBucketLabel names a bucket by its bounds, e.g. "25000-50000" or "200000+".
+func salaryBucketLabel(lower float64) string {
+	for i, boundary := range salaryBucketBoundaries {
+		if boundary == lower && i+1 < len(salaryBucketBoundaries) {
+			return fmt.Sprintf("%.0f-%.0f", lower, salaryBucketBoundaries[i+1])
+		}
+	}
+	return fmt.Sprintf("%.0f+", lower)
+}
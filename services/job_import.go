@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"jobsy-api/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const DefaultImportBatchSize = 100
+
+// ImportRow is a single parsed job from an import file. Row numbers are 1-based
+// and refer to data rows, so the CSV header is not counted.
+type ImportRow struct {
+	Row int
+	Job *models.Job
+	Err error
+}
+
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Title string `json:"title,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	DryRun   bool `json:"dryRun"`
+	Total    int  `json:"total"`
+	Valid    int  `json:"valid"`
+	Invalid  int  `json:"invalid"`
+	Inserted int  `json:"inserted"`
+	// PendingReview counts the valid Open rows held for moderation.
+	PendingReview int              `json:"pendingReview"`
+	Errors        []ImportRowError `json:"errors"`
+}
+
+type ImportOptions struct {
+	DryRun    bool
+	BatchSize int
+}
+
+// ParseJobsJSON reads a JSON array of jobs. A malformed element only fails its own row.
+func ParseJobsJSON(r io.Reader) ([]ImportRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of jobs: %w", err)
+	}
+
+	rows := make([]ImportRow, 0, len(raw))
+	for i, item := range raw {
+		var job models.Job
+		row := ImportRow{Row: i + 1, Job: &job}
+		if err := json.Unmarshal(item, &job); err != nil {
+			row.Err = err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ParseJobsCSV reads jobs from a CSV file with a header row. Recognised columns
+// (case-insensitive) are title, location, jobType, workType, status, summary,
+// description, tags, salaryMin, salaryMax, currencyType and negotiable. Tags are
+// separated by "|", ";" or ",".
+func ParseJobsCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("CSV header must contain a title column")
+	}
+
+	var rows []ImportRow
+	for rowNumber := 1; ; rowNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		// A malformed record only fails its row. Other errors, such as the body
+		// exceeding its limit, come back on every read and end the import.
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, ImportRow{Row: rowNumber, Job: &models.Job{}, Err: err})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read CSV: %w", err)
+		}
+
+		value := func(name string) string {
+			i, ok := columns[strings.ToLower(name)]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		job := &models.Job{
+			Title:       value("title"),
+			Location:    value("location"),
+			JobType:     models.JobType(value("jobType")),
+			WorkType:    models.WorkType(value("workType")),
+			Status:      models.JobStatus(value("status")),
+			Summary:     value("summary"),
+			Description: value("description"),
+			Tags:        splitTags(value("tags")),
+			Salary: models.Salary{
+				Min:          value("salaryMin"),
+				Max:          value("salaryMax"),
+				CurrencyType: value("currencyType"),
+			},
+		}
+		row := ImportRow{Row: rowNumber, Job: job}
+		if negotiable := value("negotiable"); negotiable != "" {
+			job.Salary.Negotiable, err = strconv.ParseBool(negotiable)
+			if err != nil {
+				row.Err = fmt.Errorf("negotiable must be true or false")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func splitTags(value string) []string {
+	fields := strings.FieldsFunc(value, func(r rune) bool {
+		return r == '|' || r == ';' || r == ','
+	})
+	tags := []string{}
+	for _, field := range fields {
+		if tag := strings.TrimSpace(field); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ImportJobs validates every row against the job rules and inserts the valid ones
+// for the company in batches. Nothing is written when DryRun is set.
+func (s *JobService) ImportJobs(companyID primitive.ObjectID, rows []ImportRow, opts ImportOptions) (*ImportReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultImportBatchSize
+	}
+	report := &ImportReport{DryRun: opts.DryRun, Total: len(rows), Errors: []ImportRowError{}}
+	pipeline, err := s.importPipeline(companyID)
+	if err != nil {
+		return report, err
+	}
+
+	var valid []ImportRow
+	for _, row := range rows {
+		if row.Err == nil {
+			row.Job.Company = companyID
+			prepareNewJob(row.Job)
+			row.Err = validateJob(row.Job)
+			if row.Err == nil {
+				row.Err = validatePublishable(row.Job)
+			}
+			if row.Err == nil {
+				row.Err = applyPipeline(row.Job, pipeline)
+			}
+			s.normalizeTags(row.Job)
+			s.geocodeJob(row.Job)
+		}
+		if row.Err != nil {
+			report.Errors = append(report.Errors, ImportRowError{Row: row.Row, Title: row.Job.Title, Error: row.Err.Error()})
+			continue
+		}
+		valid = append(valid, row)
+	}
+	report.Valid = len(valid)
+	report.Invalid = len(report.Errors)
+	if opts.DryRun {
+		return report, nil
+	}
+	if err := s.screenImportRows(valid, report); err != nil {
+		return report, err
+	}
+	valid, credits, err := s.reserveImportPostings(companyID, valid, report)
+	if err != nil {
+		return report, err
+	}
+	// Credits pay for the Open rows past the monthly allowance, so as many of them
+	// as there are Open rows left unsaved go back to the company.
+	unsaved := 0
+	for _, row := range valid {
+		if row.Job.Status == models.Open {
+			unsaved++
+		}
+	}
+	defer func() {
+		s.refundCredits(companyID, min(credits, unsaved))
+	}()
+	if err := s.assignImportSlugs(companyID, valid); err != nil {
+		return report, err
+	}
+
+	for start := 0; start < len(valid); start += opts.BatchSize {
+		end := min(start+opts.BatchSize, len(valid))
+		inserted, err := s.insertImportBatch(valid[start:end], report)
+		report.Inserted += len(inserted)
+		for _, job := range inserted {
+			if job.Status == models.Open {
+				unsaved--
+			}
+		}
+		if err != nil {
+			return report, err
+		}
+	}
+	report.Invalid = len(report.Errors)
+	return report, nil
+}
+
+// importPipeline is the pipeline imported jobs get unless a row brings its own.
+func (s *JobService) importPipeline(companyID primitive.ObjectID) ([]models.PipelineStage, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	return s.companyPipeline(ctx, companyID)
+}
+
+// screenImportRows holds the Open rows the moderator flags for review. They only
+// count against the plan once approved.
+func (s *JobService) screenImportRows(rows []ImportRow, report *ImportReport) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	jobs := make([]*models.Job, 0, len(rows))
+	for _, row := range rows {
+		jobs = append(jobs, row.Job)
+	}
+	if err := s.screen(ctx, jobs...); err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Status == models.PendingReview {
+			report.PendingReview++
+		}
+	}
+	return nil
+}
+
+// reserveImportPostings stamps the Open rows as published and, when a quota is
+// set, turns the Open rows beyond the plan's remaining allowance into row errors.
+// It returns the rows kept and the credits spent on them. Featured slots are only
+// handed out through the API, never by an import.
+func (s *JobService) reserveImportPostings(companyID primitive.ObjectID, rows []ImportRow, report *ImportReport) ([]ImportRow, int, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	open := 0
+	for _, row := range rows {
+		row.Job.Featured = false
+		if row.Job.Status == models.Open {
+			open++
+		}
+	}
+	allowed, credits := open, 0
+	if s.quota != nil && open > 0 {
+		var err error
+		if allowed, credits, err = s.quota.ReservePostings(ctx, companyID, open); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	now := time.Now()
+	kept := rows[:0]
+	for _, row := range rows {
+		if row.Job.Status == models.Open {
+			if allowed == 0 {
+				report.Errors = append(report.Errors, ImportRowError{Row: row.Row, Title: row.Job.Title, Error: ErrQuotaExceeded.Error()})
+				continue
+			}
+			allowed--
+			row.Job.PublishedAt = &now
+		}
+		kept = append(kept, row)
+	}
+	report.Valid = len(kept)
+	report.Invalid = len(report.Errors)
+	return kept, credits, nil
+}
+
+// assignImportSlugs gives every imported job a slug that is unique among the
+// existing jobs and the rest of the import.
+func (s *JobService) assignImportSlugs(companyID primitive.ObjectID, rows []ImportRow) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	companyName, err := s.companyName(ctx, companyID)
+	if err != nil {
+		return err
+	}
+	reserved := map[string]bool{}
+	for _, row := range rows {
+		if row.Job.Slug, err = s.jobSlug(ctx, row.Job, companyName, reserved); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertImportBatch inserts one batch unordered so a bad document does not stop the
+// rest; per-document write errors are added to the report as row errors. It
+// returns the jobs that were saved.
+func (s *JobService) insertImportBatch(batch []ImportRow, report *ImportReport) ([]*models.Job, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+
+	documents := make([]interface{}, len(batch))
+	for i, row := range batch {
+		documents[i] = row.Job
+	}
+
+	_, err := s.jobCollection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	var bulkErr mongo.BulkWriteException
+	if errors.As(err, &bulkErr) {
+		failed := map[int]bool{}
+		for _, writeErr := range bulkErr.WriteErrors {
+			row := batch[writeErr.Index]
+			failed[writeErr.Index] = true
+			report.Errors = append(report.Errors, ImportRowError{Row: row.Row, Title: row.Job.Title, Error: writeErr.Message})
+		}
+		var inserted []*models.Job
+		for i, row := range batch {
+			if !failed[i] {
+				inserted = append(inserted, row.Job)
+			}
+		}
+		s.published(inserted...)
+		return inserted, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	inserted := make([]*models.Job, len(batch))
+	for i, row := range batch {
+		inserted[i] = row.Job
+	}
+	s.published(inserted...)
+	return inserted, nil
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"jobsy-api/config"
+	"jobsy-api/models"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/language"
+)
+
+const MaxJobTranslations = 10
+
+// baseLanguage reduces a language tag such as "de-AT" to its base code "de", which
+// is how languages are stored and compared.
+func baseLanguage(value string) (string, bool) {
+	tag, err := language.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+	base, confidence := tag.Base()
+	if confidence == language.No || base.String() == "mul" {
+		return "", false
+	}
+	return base.String(), true
+}
+
+// jobLanguage is the language of the job's own title, summary and description.
+func jobLanguage(job *models.Job) string {
+	if job.Language != "" {
+		return job.Language
+	}
+	return config.DefaultLanguage()
+}
+
+// normalizeJobLanguages stores the job's language and those of its translations as
+// base codes. A translation needs at least a title.
+func normalizeJobLanguages(job *models.Job) error {
+	if job.Language != "" {
+		base, ok := baseLanguage(job.Language)
+		if !ok {
+			return fmt.Errorf("%w: invalid language %q", ErrInvalidJob, job.Language)
+		}
+		job.Language = base
+	}
+	if len(job.Translations) > MaxJobTranslations {
+		return fmt.Errorf("%w: at most %d translations", ErrInvalidJob, MaxJobTranslations)
+	}
+	seen := map[string]bool{}
+	for i := range job.Translations {
+		translation := &job.Translations[i]
+		base, ok := baseLanguage(translation.Language)
+		if !ok {
+			return fmt.Errorf("%w: invalid translation language %q", ErrInvalidJob, translation.Language)
+		}
+		if seen[base] {
+			return fmt.Errorf("%w: more than one %s translation", ErrInvalidJob, base)
+		}
+		if strings.TrimSpace(translation.Title) == "" {
+			return fmt.Errorf("%w: the %s translation needs a title", ErrInvalidJob, base)
+		}
+		seen[base] = true
+		translation.Language = base
+	}
+	return nil
+}
+
+func jobTranslation(job *models.Job, lang string) (*models.JobTranslation, bool) {
+	for i := range job.Translations {
+		if job.Translations[i].Language == lang {
+			return &job.Translations[i], true
+		}
+	}
+	return nil, false
+}
+
+// pickLanguage returns the first preferred language the job is written or
+// translated in, falling back to the job's own language.
+func pickLanguage(job *models.Job, preferred []string) string {
+	own := jobLanguage(job)
+	for _, lang := range preferred {
+		if lang == own {
+			return own
+		}
+		if _, ok := jobTranslation(job, lang); ok {
+			return lang
+		}
+	}
+	return own
+}
+
+// LocalizeJobs shows each job's title, summary and description in the best of the
+// preferred languages. Fields a translation leaves empty keep the original text.
+// ContentLanguage tells the client which language it got.
+func LocalizeJobs(preferred []string, jobs ...*models.Job) {
+	for _, job := range jobs {
+		lang := pickLanguage(job, preferred)
+		job.ContentLanguage = lang
+		translation, ok := jobTranslation(job, lang)
+		if !ok || lang == jobLanguage(job) {
+			continue
+		}
+		if translation.Title != "" {
+			job.Title = translation.Title
+		}
+		if translation.Summary != "" {
+			job.Summary = translation.Summary
+		}
+		if translation.Description != "" {
+			job.Description = translation.Description
+			job.DescriptionHTML = translation.DescriptionHTML
+		}
+	}
+}
+
+// localizedText is the text keywords are matched against, mirroring keywordFilter:
+// the job in the query language when one is set, otherwise every language.
+func localizedText(job *models.Job, lang string) string {
+	texts := []string{job.Title, job.Summary, job.Description}
+	if translation, ok := jobTranslation(job, lang); ok && lang != jobLanguage(job) {
+		texts = []string{translation.Title, translation.Summary, translation.Description}
+	} else if lang == "" {
+		for _, translation := range job.Translations {
+			texts = append(texts, translation.Title, translation.Summary, translation.Description)
+		}
+	}
+	return strings.Join(append(texts, job.Tags...), " ")
+}
+
+// keywordFilter matches one keyword. With a query language the keyword must be in
+// the text shown in that language: the job's own text when it is written in it,
+// the translation when there is one, and the original text otherwise.
+func keywordFilter(word, lang string) bson.M {
+	pattern := primitive.Regex{Pattern: regexp.QuoteMeta(word), Options: "i"}
+	text := func(prefix string) bson.M {
+		return bson.M{"$or": bson.A{
+			bson.M{prefix + "title": pattern},
+			bson.M{prefix + "summary": pattern},
+			bson.M{prefix + "description": pattern},
+		}}
+	}
+	if lang == "" {
+		return bson.M{"$or": bson.A{
+			text(""),
+			text("translations."),
+			bson.M{"tags": pattern},
+		}}
+	}
+
+	// Jobs saved without a language are in the default one.
+	written := bson.A{lang}
+	if lang == config.DefaultLanguage() {
+		written = append(written, nil)
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"$and": bson.A{bson.M{"language": bson.M{"$in": written}}, text("")}},
+		bson.M{"translations": bson.M{"$elemMatch": bson.M{"language": lang, "$or": text("")["$or"]}}},
+		bson.M{"$and": bson.A{
+			bson.M{"language": bson.M{"$nin": written}},
+			bson.M{"translations.language": bson.M{"$ne": lang}},
+			text(""),
+		}},
+		bson.M{"tags": pattern},
+	}}
+}
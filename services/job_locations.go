@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"jobsy-api/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const MaxJobLocations = 20
+
+// normalizeLocations keeps Location/Geo and Locations in step. A job sent with
+// only the location string gets a one entry list, and a job sent with a list takes
+// its first entry as the primary location.
+func normalizeLocations(job *models.Job) {
+	for i := range job.Locations {
+		location := &job.Locations[i]
+		location.City = strings.TrimSpace(location.City)
+		location.Region = strings.TrimSpace(location.Region)
+		location.Country = strings.TrimSpace(location.Country)
+	}
+	if len(job.Locations) == 0 {
+		if strings.TrimSpace(job.Location) != "" {
+			job.Locations = []models.JobLocation{{City: strings.TrimSpace(job.Location), Geo: job.Geo}}
+		}
+		return
+	}
+	job.Location = job.Locations[0].Label()
+	job.Geo = job.Locations[0].Geo
+}
+
+func validateLocations(locations []models.JobLocation) error {
+	if len(locations) > MaxJobLocations {
+		return fmt.Errorf("%w: at most %d locations", ErrInvalidJob, MaxJobLocations)
+	}
+	for i, location := range locations {
+		if location.Label() == "" {
+			return fmt.Errorf("%w: location %d needs a city, region or country, or a Remote work type", ErrInvalidJob, i+1)
+		}
+		if location.WorkType != "" && !isValidWorkType(location.WorkType) {
+			return fmt.Errorf("%w: invalid work type %q for %s", ErrInvalidJob, location.WorkType, location.Label())
+		}
+		if location.Geo != nil && !location.Geo.Valid() {
+			return fmt.Errorf("%w: geo for %s must be a GeoJSON point with [longitude, latitude]", ErrInvalidJob, location.Label())
+		}
+	}
+	return nil
+}
+
+// jobLocations returns the job's locations, treating a job saved before it had a
+// list as having its single location.
+func jobLocations(job *models.Job) []models.JobLocation {
+	if len(job.Locations) > 0 {
+		return job.Locations
+	}
+	if job.Location == "" && job.Geo == nil {
+		return nil
+	}
+	return []models.JobLocation{{City: job.Location, Geo: job.Geo}}
+}
+
+// locationWorkType is the work type at a location, falling back to the job's.
+func locationWorkType(job *models.Job, location models.JobLocation) models.WorkType {
+	if location.WorkType != "" {
+		return location.WorkType
+	}
+	return job.WorkType
+}
+
+// jobPoints returns the coordinates of every geocoded location of the job.
+func jobPoints(job *models.Job) []*models.GeoPoint {
+	var points []*models.GeoPoint
+	for _, location := range jobLocations(job) {
+		if location.Geo.Valid() {
+			points = append(points, location.Geo)
+		}
+	}
+	return points
+}
+
+// matchJobLocation resolves the location an applicant picked, by label or city, to
+// the label of one of the job's locations.
+func matchJobLocation(job *models.Job, name string) (string, bool) {
+	key := placeKey(name)
+	for _, location := range jobLocations(job) {
+		if key == placeKey(location.Label()) || (location.City != "" && key == placeKey(location.City)) {
+			return location.Label(), true
+		}
+	}
+	return "", false
+}
+
+// BackfillLocations gives jobs saved before they had a list of locations a one
+// entry list, so location filters and radius searches find them.
+func (s *JobService) BackfillLocations() (int, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	filter := bson.M{"locations": bson.M{"$exists": false}, "location": bson.M{"$nin": bson.A{nil, ""}}}
+	cursor, err := s.jobCollection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var jobs []*models.Job
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return 0, err
+	}
+
+	var writes []mongo.WriteModel
+	for _, job := range jobs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": job.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"locations": jobLocations(job)}}))
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+	result, err := s.jobCollection.BulkWrite(ctx, writes)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
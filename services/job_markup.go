@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"html"
+	"jobsy-api/markup"
+	"jobsy-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// renderDescriptions derives the HTML of the job's description and of each
+// translation's from the Markdown source, replacing whatever the client sent.
+func renderDescriptions(job *models.Job) {
+	job.DescriptionHTML = markup.Render(job.Description)
+	for i := range job.Translations {
+		job.Translations[i].DescriptionHTML = markup.Render(job.Translations[i].Description)
+	}
+}
+
+// descriptionHTML is the sanitized description for feeds. Jobs saved before
+// descriptions were rendered get their text escaped instead.
+func descriptionHTML(job *models.Job) string {
+	if job.DescriptionHTML != "" {
+		return job.DescriptionHTML
+	}
+	return html.EscapeString(job.Description)
+}
+
+// BackfillDescriptions renders the description of jobs saved before descriptions
+// were rendered.
+func (s *JobService) BackfillDescriptions() (int, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	filter := bson.M{"descriptionHtml": bson.M{"$exists": false}, "description": bson.M{"$nin": bson.A{nil, ""}}}
+	cursor, err := s.jobCollection.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var jobs []*models.Job
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return 0, err
+	}
+
+	var writes []mongo.WriteModel
+	for _, job := range jobs {
+		renderDescriptions(job)
+		set := bson.M{"descriptionHtml": job.DescriptionHTML}
+		if len(job.Translations) > 0 {
+			set["translations"] = job.Translations
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": job.ID}).
+			SetUpdate(bson.M{"$set": set}))
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+	result, err := s.jobCollection.BulkWrite(ctx, writes)
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
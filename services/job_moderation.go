@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// substantialEditSimilarity is how similar the text of an edited Open job must stay
+// to the reviewed version to go live without another review.
+const substantialEditSimilarity = 0.8
+
+var ErrJobNotPending = errors.New("job is not pending review")
+
+// JobModerator screens jobs that are about to go live. Screen moves the jobs that
+// need a human review to PendingReview and records why in job.Moderation.
+// ScreenText does the same with only the rules on the job's text, for small edits
+// of jobs that were screened already.
+type JobModerator interface {
+	Screen(ctx context.Context, jobs ...*models.Job) error
+	ScreenText(ctx context.Context, job *models.Job) error
+}
+
+// SetModerator holds new Open jobs, and edited ones, for review when the moderator
+// asks for it.
+func (s *JobService) SetModerator(moderator JobModerator) {
+	s.moderator = moderator
+}
+
+// hiddenJobStatuses are the statuses of jobs only their owner and admins may see.
+var hiddenJobStatuses = []models.JobStatus{models.Draft, models.PendingReview, models.JobRejected}
+
+// canSeeJob reports whether the viewer, zero when anonymous, may see the job and
+// its moderation details.
+func canSeeJob(job *models.Job, viewerID primitive.ObjectID, role string) bool {
+	return role == models.RoleAdmin || (!viewerID.IsZero() && job.Company == viewerID)
+}
+
+// revealModeration includes the moderation details of jobs sent to their owner or
+// an admin.
+func revealModeration(jobs ...*models.Job) {
+	for _, job := range jobs {
+		if job.Moderation != nil {
+			job.Moderation.Reveal()
+		}
+	}
+}
+
+// excludeUnreviewed hides jobs waiting for review or rejected by it from listings
+// that do not ask for a status.
+func excludeUnreviewed(filter bson.M) bson.M {
+	if _, ok := filter["status"]; !ok {
+		filter["status"] = bson.M{"$nin": bson.A{models.PendingReview, models.JobRejected}}
+	}
+	return filter
+}
+
+// screen runs the moderator over the jobs that are about to become Open.
+func (s *JobService) screen(ctx context.Context, jobs ...*models.Job) error {
+	if s.moderator == nil {
+		return nil
+	}
+	var open []*models.Job
+	for _, job := range jobs {
+		if job.Status == models.Open {
+			open = append(open, job)
+		}
+	}
+	if len(open) == 0 {
+		return nil
+	}
+	return s.moderator.Screen(ctx, open...)
+}
+
+// screenUpdate decides whether an update sends the job (back) to review. A job
+// that becomes Open is screened in full, and so is an Open or pending job whose
+// text moves substantially away from the reviewed version; any other change to
+// its text goes through the text rules. A pending job stays pending until a
+// reviewer decides, whatever status is asked for.
+func (s *JobService) screenUpdate(ctx context.Context, previous *models.Job, updateData bson.M) error {
+	if s.moderator == nil {
+		return nil
+	}
+	target := previous.Status
+	if status, ok := updateData["status"].(models.JobStatus); ok {
+		target = status
+	} else if previous.Status == models.PendingReview && previous.Moderation != nil {
+		target = previous.Moderation.RequestedStatus
+	}
+	if target != models.Open {
+		return nil
+	}
+
+	candidate, err := mergeJobUpdate(previous, updateData)
+	if err != nil {
+		return err
+	}
+	screened := previous.Status == models.Open || previous.Status == models.PendingReview
+	text := jobText(candidate)
+	switch {
+	case screened && text == jobText(previous):
+		candidate.Status = previous.Status
+	case screened && textSimilarity(reviewedText(previous), text) >= substantialEditSimilarity:
+		candidate.Status = previous.Status
+		if err := s.moderator.ScreenText(ctx, candidate); err != nil {
+			return err
+		}
+	default:
+		candidate.Status = models.Open
+		if err := s.moderator.Screen(ctx, candidate); err != nil {
+			return err
+		}
+	}
+	updateData["status"] = candidate.Status
+	if candidate.Moderation != nil {
+		updateData["moderation"] = candidate.Moderation
+	}
+	return nil
+}
+
+// reviewedText is the text of the job as it was last screened or approved. Jobs
+// screened before that was recorded fall back to their current text.
+func reviewedText(job *models.Job) string {
+	if job.Moderation != nil && job.Moderation.ReviewedText != "" {
+		return job.Moderation.ReviewedText
+	}
+	return jobText(job)
+}
+
+// mergeJobUpdate returns the job as it will look once updateData is applied.
+func mergeJobUpdate(previous *models.Job, updateData bson.M) (*models.Job, error) {
+	raw, err := bson.Marshal(previous)
+	if err != nil {
+		return nil, err
+	}
+	var document bson.M
+	if err := bson.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	for field, value := range updateData {
+		document[field] = value
+	}
+	if raw, err = bson.Marshal(document); err != nil {
+		return nil, err
+	}
+	var merged models.Job
+	err = bson.Unmarshal(raw, &merged)
+	return &merged, err
+}
+
+// GetModerationQueue returns the jobs waiting for review, oldest submission first.
+func (s *JobService) GetModerationQueue() ([]*models.Job, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"moderation.submittedAt": 1})
+	cursor, err := s.jobCollection.Find(ctx, excludeDeleted(bson.M{"status": models.PendingReview}), opts)
+	if err != nil {
+		return nil, err
+	}
+	jobs := []*models.Job{}
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	s.canonical(jobs...)
+	revealModeration(jobs...)
+	return jobs, nil
+}
+
+// decideModeration approves or rejects a pending job. An approved job takes the
+// status it was submitted with, which for Open jobs is subject to the plan quota.
+func (s *JobService) decideModeration(id, reviewerID primitive.ObjectID, decision models.ModerationDecision, reason string) (*models.Job, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var job models.Job
+	err := s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"_id": id})).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.PendingReview {
+		return nil, ErrJobNotPending
+	}
+
+	now := time.Now()
+	moderation := models.JobModeration{RequestedStatus: models.Open, SubmittedAt: now}
+	if job.Moderation != nil {
+		moderation = *job.Moderation
+	}
+	moderation.Decision = decision
+	moderation.Reason = reason
+	moderation.ReviewedBy = reviewerID
+	moderation.ReviewedAt = &now
+	if decision == models.ModerationApproved {
+		moderation.ReviewedText = jobText(&job)
+	}
+	set := bson.M{"moderation": moderation, "updatedAt": now}
+
+	credits := 0
+	if decision == models.ModerationApproved {
+		job.Status = moderation.RequestedStatus
+		if job.Status == models.Open {
+			if credits, err = s.allowPublish(ctx, &job, job.PublishedAt == nil); err != nil {
+				return nil, err
+			}
+			if job.PublishedAt == nil {
+				set["publishedAt"] = now
+			}
+		}
+		set["status"] = job.Status
+	} else {
+		set["status"] = models.JobRejected
+	}
+
+	// Matching on the status keeps two reviewers from deciding the same job twice.
+	filter := excludeDeleted(bson.M{"_id": id, "status": models.PendingReview})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Job
+	err = s.jobCollection.FindOneAndUpdate(ctx, filter, bson.M{"$set": set}, opts).Decode(&updated)
+	if err != nil {
+		// The reviewer who lost the race, or whose write failed, paid for nothing.
+		s.refundCredits(job.Company, credits)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.canonical(&updated)
+	s.published(&updated)
+	revealModeration(&updated)
+	return &updated, nil
+}
+
+// jobText is the text moderation looks at: the job's own and that of each of its
+// translations.
+func jobText(job *models.Job) string {
+	parts := []string{job.Title, job.Summary, job.Description}
+	for _, translation := range job.Translations {
+		parts = append(parts, translation.Title, translation.Summary, translation.Description)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// textWords lowercases text and splits it into words, dropping punctuation.
+func textWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// textShingles returns the three-word sequences of a text, or its single words when
+// it is shorter than that.
+func textShingles(text string) map[string]bool {
+	words := textWords(text)
+	shingles := map[string]bool{}
+	if len(words) < 3 {
+		for _, word := range words {
+			shingles[word] = true
+		}
+		return shingles
+	}
+	for i := 0; i+3 <= len(words); i++ {
+		shingles[strings.Join(words[i:i+3], " ")] = true
+	}
+	return shingles
+}
+
+// textSimilarity is the Jaccard similarity of two texts' shingles, from 0 for
+// nothing in common to 1 for the same text.
+func textSimilarity(a, b string) float64 {
+	return shingleSimilarity(textShingles(a), textShingles(b))
+}
+
+func shingleSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	common := 0
+	for shingle := range a {
+		if b[shingle] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
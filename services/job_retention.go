@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultJobRetention = 30 * 24 * time.Hour
+	purgeBatchSize      = 500
+)
+
+var ErrRestoreWindowExpired = errors.New("job can no longer be restored")
+
+// PurgeHook removes data that belongs to jobs about to be purged, e.g. saved jobs
+// or analytics events kept by other services.
+type PurgeHook func(ctx context.Context, jobIDs []primitive.ObjectID) error
+
+type PurgeReport struct {
+	Jobs         int `json:"jobs"`
+	Applications int `json:"applications"`
+}
+
+// excludeDeleted adds the soft delete condition to a job filter. Every query that
+// serves jobs to users must go through it.
+func excludeDeleted(filter bson.M) bson.M {
+	filter["deletedAt"] = bson.M{"$exists": false}
+	return filter
+}
+
+// SetRetention sets how long deleted jobs can be restored before they are purged.
+func (s *JobService) SetRetention(retention time.Duration) {
+	s.retention = retention
+}
+
+// OnPurge registers a hook that runs before jobs are permanently removed.
+func (s *JobService) OnPurge(hook PurgeHook) {
+	s.purgeHooks = append(s.purgeHooks, hook)
+}
+
+// DeleteJob soft deletes a job. It disappears from every listing straight away and
+// can be restored by its company until the retention window has passed.
+func (s *JobService) DeleteJob(id string) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	objectID, _ := primitive.ObjectIDFromHex(id)
+	now := time.Now()
+	filter := excludeDeleted(bson.M{"_id": objectID})
+	update := bson.M{"$set": bson.M{"deletedAt": now, "updatedAt": now}}
+	result, err := s.jobCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// GetDeletedJobs lists a company's jobs that are deleted but can still be restored.
+func (s *JobService) GetDeletedJobs(companyID primitive.ObjectID) ([]*models.Job, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	filter := bson.M{"company": companyID, "deletedAt": bson.M{"$gt": time.Now().Add(-s.retention)}}
+	opts := options.Find().SetSort(bson.M{"deletedAt": -1})
+	cursor, err := s.jobCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := []*models.Job{}
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	s.canonical(jobs...)
+	return jobs, nil
+}
+
+// RestoreJob brings back a deleted job owned by the company. Deleted jobs are not
+// visible to the ownership middleware, so the company is checked here.
+func (s *JobService) RestoreJob(id, companyID primitive.ObjectID) (*models.Job, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var job models.Job
+	filter := bson.M{"_id": id, "company": companyID, "deletedAt": bson.M{"$exists": true}}
+	err := s.jobCollection.FindOne(ctx, filter).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.DeletedAt.Before(time.Now().Add(-s.retention)) {
+		return nil, ErrRestoreWindowExpired
+	}
+	if job.Status == models.Open {
+		// Restoring is not a new posting, so no credits are spent here.
+		if _, err := s.allowPublish(ctx, &job, false); err != nil {
+			return nil, err
+		}
+	}
+
+	update := bson.M{"$unset": bson.M{"deletedAt": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var restored models.Job
+	if err := s.jobCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&restored); err != nil {
+		return nil, err
+	}
+	s.canonical(&restored)
+	return &restored, nil
+}
+
+// PurgeDeletedJobs permanently removes jobs deleted longer ago than the retention
+// window, with their applications and whatever the purge hooks clean up. Dependent
+// data goes first, so an interrupted purge is simply finished by the next run.
+func (s *JobService) PurgeDeletedJobs() (*PurgeReport, error) {
+	report := &PurgeReport{}
+	cutoff := time.Now().Add(-s.retention)
+	for {
+		purged, applications, err := s.purgeBatch(cutoff)
+		report.Jobs += purged
+		report.Applications += applications
+		if err != nil || purged < purgeBatchSize {
+			return report, err
+		}
+	}
+}
+
+func (s *JobService) purgeBatch(cutoff time.Time) (int, int, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	filter := bson.M{"deletedAt": bson.M{"$lte": cutoff}}
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(purgeBatchSize)
+	cursor, err := s.jobCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	var jobs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return 0, 0, err
+	}
+	if len(jobs) == 0 {
+		return 0, 0, nil
+	}
+
+	jobIDs := make([]primitive.ObjectID, len(jobs))
+	for i, job := range jobs {
+		jobIDs[i] = job.ID
+	}
+	for _, hook := range s.purgeHooks {
+		if err := hook(ctx, jobIDs); err != nil {
+			return 0, 0, err
+		}
+	}
+	applications, err := s.applicantCollection.DeleteMany(ctx, bson.M{"jobId": bson.M{"$in": jobIDs}})
+	if err != nil {
+		return 0, 0, err
+	}
+	deleted, err := s.jobCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": jobIDs}, "deletedAt": bson.M{"$lte": cutoff}})
+	if err != nil {
+		return 0, int(applications.DeletedCount), err
+	}
+	return int(deleted.DeletedCount), int(applications.DeletedCount), nil
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"jobsy-api/models"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// buildJobFilter translates the non-geographic part of a listing query into a Mongo
+// filter. jobMatchesQuery below must stay in step with it, since saved-search
+// alerts match freshly published jobs in memory rather than re-querying.
+func (s *JobService) buildJobFilter(query *models.JobQuery) (bson.M, error) {
+	filter := bson.M{}
+	var and bson.A
+
+	lang := ""
+	if query.Lang != "" {
+		var ok bool
+		if lang, ok = baseLanguage(query.Lang); !ok {
+			return nil, fmt.Errorf("%w: invalid language %q", ErrInvalidJobQuery, query.Lang)
+		}
+	}
+	for _, word := range strings.Fields(query.Keywords) {
+		and = append(and, keywordFilter(word, lang))
+	}
+	if query.JobType != "" {
+		if !isValidJobType(query.JobType) {
+			return nil, fmt.Errorf("%w: invalid job type %q", ErrInvalidJobQuery, query.JobType)
+		}
+		filter["jobType"] = query.JobType
+	}
+	if query.WorkType != "" {
+		if !isValidWorkType(query.WorkType) {
+			return nil, fmt.Errorf("%w: invalid work type %q", ErrInvalidJobQuery, query.WorkType)
+		}
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"workType": query.WorkType},
+			bson.M{"locations.workType": query.WorkType},
+		}})
+	}
+	if query.Status != "" {
+		if !isValidJobStatus(query.Status) {
+			return nil, fmt.Errorf("%w: invalid job status %q", ErrInvalidJobQuery, query.Status)
+		}
+		filter["status"] = query.Status
+	}
+	if location := strings.TrimSpace(query.Location); location != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(location), Options: "i"}
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"location": pattern},
+			bson.M{"locations.city": pattern},
+			bson.M{"locations.region": pattern},
+			bson.M{"locations.country": pattern},
+		}})
+	}
+	if tags := s.queryTags(query); len(tags) > 0 {
+		patterns := bson.A{}
+		for _, tag := range tags {
+			patterns = append(patterns, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(tag) + "$", Options: "i"})
+		}
+		filter["tags"] = bson.M{"$in": patterns}
+	}
+	if query.Company != "" {
+		companyID, err := primitive.ObjectIDFromHex(query.Company)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid company ID", ErrInvalidJobQuery)
+		}
+		filter["company"] = companyID
+	}
+
+	if len(and) > 0 {
+		filter["$and"] = and
+	}
+	return excludeUnreviewed(filter), nil
+}
+
+// queryTags accepts both repeated ?tags= parameters and comma separated values.
+// Tags are looked up under their canonical names, as jobs are saved with them, and
+// as given, for jobs saved before an alias was added.
+func (s *JobService) queryTags(query *models.JobQuery) []string {
+	var tags []string
+	for _, value := range query.Tags {
+		tags = append(tags, splitTags(value)...)
+	}
+	if s.tagNormalizer == nil || len(tags) == 0 {
+		return tags
+	}
+	for _, tag := range s.tagNormalizer.NormalizeTags(tags) {
+		if !hasAnyTag(tags, []string{tag}) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// jobMatchesQuery reports whether a single job satisfies the query, mirroring
+// buildJobFilter and the geographic part of jobListPipeline.
+func (s *JobService) jobMatchesQuery(query *models.JobQuery, job *models.Job) bool {
+	lang, _ := baseLanguage(query.Lang)
+	text := strings.ToLower(localizedText(job, lang))
+	for _, word := range strings.Fields(query.Keywords) {
+		if !strings.Contains(text, strings.ToLower(word)) {
+			return false
+		}
+	}
+	if query.JobType != "" && query.JobType != job.JobType {
+		return false
+	}
+	if query.WorkType != "" && !hasWorkType(job, query.WorkType) {
+		return false
+	}
+	if query.Status != "" && query.Status != job.Status {
+		return false
+	}
+	if location := strings.TrimSpace(query.Location); location != "" && !inLocation(job, location) {
+		return false
+	}
+	if tags := s.queryTags(query); len(tags) > 0 && !hasAnyTag(job.Tags, tags) {
+		return false
+	}
+	if query.Company != "" && query.Company != job.Company.Hex() {
+		return false
+	}
+
+	if query.BBox != "" {
+		box, err := parseBoundingBox(query.BBox)
+		if err != nil {
+			return false
+		}
+		corners := box["coordinates"].([][][]float64)[0]
+		inside := false
+		for _, point := range jobPoints(job) {
+			if point.Lng() >= corners[0][0] && point.Lng() <= corners[2][0] && point.Lat() >= corners[0][1] && point.Lat() <= corners[2][1] {
+				inside = true
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	point, err := s.searchPoint(query)
+	if err != nil {
+		return false
+	}
+	if point != nil {
+		radiusKm := query.RadiusKm
+		if radiusKm == 0 {
+			radiusKm = DefaultSearchRadiusKm
+		}
+		near := false
+		for _, location := range jobPoints(job) {
+			if haversineKm(point, location) <= radiusKm {
+				near = true
+			}
+		}
+		if !near {
+			return false
+		}
+	}
+	return true
+}
+
+// hasWorkType reports whether the job or any of its locations has the work type.
+func hasWorkType(job *models.Job, workType models.WorkType) bool {
+	if job.WorkType == workType {
+		return true
+	}
+	for _, location := range job.Locations {
+		if location.WorkType == workType {
+			return true
+		}
+	}
+	return false
+}
+
+// inLocation reports whether the location string or any part of a listed location
+// contains the searched text.
+func inLocation(job *models.Job, search string) bool {
+	search = strings.ToLower(search)
+	fields := []string{job.Location}
+	for _, location := range job.Locations {
+		fields = append(fields, location.City, location.Region, location.Country)
+	}
+	for _, field := range fields {
+		if field != "" && strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyTag(jobTags, wanted []string) bool {
+	for _, tag := range jobTags {
+		for _, want := range wanted {
+			if strings.EqualFold(strings.TrimSpace(tag), strings.TrimSpace(want)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidateQuery checks a query without running it, e.g. before saving a search.
+func (s *JobService) ValidateQuery(query *models.JobQuery) error {
+	_, err := s.jobListPipeline(query, nil)
+	return err
+}
+
+// findJobsPostedSince runs a saved query over the Open jobs created after since,
+// newest first.
+func (s *JobService) findJobsPostedSince(query *models.JobQuery, since time.Time, limit int64) ([]*JobListing, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	pipeline, err := s.jobListPipeline(query, bson.M{"status": models.Open, "createdAt": bson.M{"$gt": since}})
+	if err != nil {
+		return nil, err
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.M{"createdAt": -1}}},
+		bson.D{{Key: "$limit", Value: limit}},
+	)
+
+	cursor, err := s.jobCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	jobs := []*JobListing{}
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
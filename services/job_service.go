@@ -3,8 +3,13 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"jobsy-api/config"
 	"jobsy-api/models"
+	"log"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,55 +19,525 @@ import (
 )
 
 type JobService struct {
-	jobCollection *mongo.Collection
+	jobCollection       *mongo.Collection
+	applicantCollection *mongo.Collection
+	companyCollection   *mongo.Collection
+	baseURL             string
+	geocoder            Geocoder
+	tagNormalizer       TagNormalizer
+	quota               PublishQuota
+	moderator           JobModerator
+	publishHooks        []func(job *models.Job)
+	purgeHooks          []PurgeHook
+	retention           time.Duration
 }
 
-func NewJobService(db *mongo.Client, collection string) *JobService {
+func NewJobService(db *mongo.Client, jobCollectionName, applicantCollectionName, companyCollectionName string) *JobService {
 	return &JobService{
-		jobCollection: db.Database("jobsy-api").Collection(collection),
+		jobCollection:       db.Database("jobsy-api").Collection(jobCollectionName),
+		applicantCollection: db.Database("jobsy-api").Collection(applicantCollectionName),
+		companyCollection:   db.Database("jobsy-api").Collection(companyCollectionName),
+		baseURL:             config.PublicBaseURL(),
+		geocoder:            NewOfflineGeocoder(),
+		retention:           DefaultJobRetention,
 	}
 }
 
-func (s *JobService) CreateJob(job *models.Job) (*mongo.InsertOneResult, error) {
+var (
+	ErrInvalidJob      = errors.New("invalid job")
+	ErrInvalidJobQuery = errors.New("invalid job query")
+)
+
+const (
+	DefaultSearchRadiusKm = 25
+	MaxSearchRadiusKm     = 20000
+)
+
+// JobListing is a job as returned by the listing, with the distance from the
+// search point when a radius search was requested.
+type JobListing struct {
+	models.Job `bson:",inline"`
+	DistanceKm *float64 `bson:"distanceKm,omitempty" json:"distanceKm,omitempty"`
+}
+
+func (s *JobService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.jobCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "geo", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "locations.geo", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "deletedAt", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{
+			Keys:    bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"slug": bson.M{"$exists": true}}),
+		},
+		{Keys: bson.D{{Key: "previousSlugs", Value: 1}}},
+	})
+	return err
+}
+
+// CreateJob stores a new job for job.Company. A job sent without a status is
+// created Open; unlike an imported one, it does not have to be complete.
+func (s *JobService) CreateJob(job *models.Job) (result *mongo.InsertOneResult, err error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
+	prepareNewJob(job)
+	if err := validateJob(job); err != nil {
+		return nil, err
+	}
+	pipeline, err := s.companyPipeline(ctx, job.Company)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyPipeline(job, pipeline); err != nil {
+		return nil, err
+	}
+	s.normalizeTags(job)
+	s.geocodeJob(job)
+	if err := s.screen(ctx, job); err != nil {
+		return nil, err
+	}
+	if job.Status == models.Open {
+		var credits int
+		if credits, err = s.allowPublish(ctx, job, true); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil {
+				s.refundCredits(job.Company, credits)
+			}
+		}()
+		now := time.Now()
+		job.PublishedAt = &now
+	}
+	companyName, err := s.companyName(ctx, job.Company)
+	if err != nil {
+		return nil, err
+	}
+	if job.Slug, err = s.jobSlug(ctx, job, companyName, nil); err != nil {
+		return nil, err
+	}
+	result, err = s.jobCollection.InsertOne(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+	s.canonical(job)
+	s.published(job)
+	return result, nil
+}
+
+// SetPublishQuota enforces plan limits whenever a job becomes Open or featured.
+func (s *JobService) SetPublishQuota(quota PublishQuota) {
+	s.quota = quota
+}
+
+// allowPublish returns the credits the quota spent on the job, which the caller
+// hands back through refundCredits if the job is not saved after all.
+func (s *JobService) allowPublish(ctx context.Context, job *models.Job, firstPublish bool) (int, error) {
+	if s.quota == nil {
+		return 0, nil
+	}
+	return s.quota.AllowPublish(ctx, job, firstPublish)
+}
+
+// refundCredits returns credits spent on a publish that failed. It gets its own
+// context, as the request's may be the reason the write failed.
+func (s *JobService) refundCredits(companyID primitive.ObjectID, credits int) {
+	if s.quota == nil || credits <= 0 {
+		return
+	}
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if err := s.quota.RefundCredits(ctx, companyID, credits); err != nil {
+		log.Printf("quota: failed to refund %d credits to company %s: %v", credits, companyID.Hex(), err)
+	}
+}
+
+// SetFeatured features or unfeatures a job. Featuring an Open job takes one of the
+// plan's featured slots.
+func (s *JobService) SetFeatured(id primitive.ObjectID, featured bool) (*models.Job, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	job, err := s.GetJobByID(id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if featured && job.Status == models.Open && s.quota != nil {
+		if err := s.quota.AllowFeatured(ctx, job); err != nil {
+			return nil, err
+		}
+	}
+
+	update := bson.M{"$set": bson.M{"featured": featured, "updatedAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Job
+	if err := s.jobCollection.FindOneAndUpdate(ctx, excludeDeleted(bson.M{"_id": id}), update, opts).Decode(&updated); err != nil {
+		return nil, err
+	}
+	s.canonical(&updated)
+	return &updated, nil
+}
+
+// SetTagNormalizer makes every saved job use canonical tag names.
+func (s *JobService) SetTagNormalizer(normalizer TagNormalizer) {
+	s.tagNormalizer = normalizer
+}
+
+func (s *JobService) normalizeTags(job *models.Job) {
+	if s.tagNormalizer != nil && len(job.Tags) > 0 {
+		job.Tags = s.tagNormalizer.NormalizeTags(job.Tags)
+	}
+}
+
+// OnPublish registers fn to be called with every job that becomes Open, whether it
+// was created Open, imported or moved out of Draft. Hooks run in the background so
+// they never slow down or fail the request that published the job.
+func (s *JobService) OnPublish(fn func(job *models.Job)) {
+	s.publishHooks = append(s.publishHooks, fn)
+}
+
+func (s *JobService) published(jobs ...*models.Job) {
+	var open []*models.Job
+	for _, job := range jobs {
+		if job.Status == models.Open {
+			open = append(open, job)
+		}
+	}
+	if len(open) == 0 || len(s.publishHooks) == 0 {
+		return
+	}
+	go func() {
+		for _, job := range open {
+			for _, hook := range s.publishHooks {
+				hook(job)
+			}
+		}
+	}()
+}
+
+// geocodeJob fills in coordinates for each location unless the client already sent
+// them. Remote locations and places the geocoder does not know are left without a
+// point.
+func (s *JobService) geocodeJob(job *models.Job) {
+	for i := range job.Locations {
+		location := &job.Locations[i]
+		if location.Geo != nil || location.WorkType == models.Remote {
+			continue
+		}
+		if point, ok := s.geocoder.Geocode(location.Label()); ok {
+			location.Geo = point
+		}
+	}
+	if len(job.Locations) > 0 {
+		job.Geo = job.Locations[0].Geo
+	}
+}
+
+func prepareNewJob(job *models.Job) {
 	job.ID = primitive.NewObjectID()
 	job.CreatedAt = time.Now()
 	job.UpdatedAt = time.Now()
 	job.Applicants = 0
-	return s.jobCollection.InsertOne(ctx, job)
+	job.ApplicantCounts = nil
+	job.Slug = ""
+	job.PreviousSlugs = nil
+	job.DeletedAt = nil
+	job.PublishedAt = nil
+	job.Moderation = nil
+	if job.Status == "" {
+		job.Status = models.Open
+	}
+	if job.Language == "" {
+		job.Language = config.DefaultLanguage()
+	}
+	normalizeLocations(job)
+	renderDescriptions(job)
+	if job.WorkType == "" && len(job.Locations) > 0 {
+		job.WorkType = job.Locations[0].WorkType
+	}
+}
+
+// validateJob checks the rules every stored job must follow: a title, an owner, and
+// valid values in the fields that are set.
+func validateJob(job *models.Job) error {
+	if strings.TrimSpace(job.Title) == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidJob)
+	}
+	if job.Company.IsZero() {
+		return fmt.Errorf("%w: company is required", ErrInvalidJob)
+	}
+	if !isValidJobStatus(job.Status) {
+		return fmt.Errorf("%w: invalid job status %q", ErrInvalidJob, job.Status)
+	}
+	if job.JobType != "" && !isValidJobType(job.JobType) {
+		return fmt.Errorf("%w: invalid job type %q", ErrInvalidJob, job.JobType)
+	}
+	if job.WorkType != "" && !isValidWorkType(job.WorkType) {
+		return fmt.Errorf("%w: invalid work type %q", ErrInvalidJob, job.WorkType)
+	}
+	if job.Geo != nil && !job.Geo.Valid() {
+		return fmt.Errorf("%w: geo must be a GeoJSON point with [longitude, latitude]", ErrInvalidJob)
+	}
+	if err := validateLocations(job.Locations); err != nil {
+		return err
+	}
+	if err := normalizeJobLanguages(job); err != nil {
+		return err
+	}
+
+	min, minOk := parseSalaryAmount(job.Salary.Min)
+	max, maxOk := parseSalaryAmount(job.Salary.Max)
+	if job.Salary.Min != "" && !minOk {
+		return fmt.Errorf("%w: salary min must be a number", ErrInvalidJob)
+	}
+	if job.Salary.Max != "" && !maxOk {
+		return fmt.Errorf("%w: salary max must be a number", ErrInvalidJob)
+	}
+	if minOk && maxOk && min > max {
+		return fmt.Errorf("%w: salary min cannot be greater than salary max", ErrInvalidJob)
+	}
+	return nil
+}
+
+// validatePublishable makes imported jobs, other than drafts, complete enough to
+// publish. Jobs created through the API are not held to it, as they never were.
+func validatePublishable(job *models.Job) error {
+	if job.Status == models.Draft {
+		return nil
+	}
+	if strings.TrimSpace(job.Location) == "" {
+		return fmt.Errorf("%w: location is required", ErrInvalidJob)
+	}
+	if job.JobType == "" {
+		return fmt.Errorf("%w: job type is required", ErrInvalidJob)
+	}
+	if job.WorkType == "" {
+		return fmt.Errorf("%w: work type is required", ErrInvalidJob)
+	}
+	if strings.TrimSpace(job.Description) == "" {
+		return fmt.Errorf("%w: description is required", ErrInvalidJob)
+	}
+	return nil
+}
+
+// parseSalaryAmount reads salary strings such as "120000" or "1,20,000".
+func parseSalaryAmount(value string) (float64, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ",", "")
+	if value == "" {
+		return 0, false
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return 0, false
+	}
+	return amount, true
+}
+
+// CreateJobFromTemplate copies the template content into a new Draft job for the template's company.
+func (s *JobService) CreateJobFromTemplate(template *models.JobTemplate) (*models.Job, error) {
+	job := &models.Job{
+		Title:       template.Title,
+		Company:     template.Company,
+		Location:    template.Location,
+		JobType:     template.JobType,
+		WorkType:    template.WorkType,
+		Salary:      template.Salary,
+		Summary:     template.Summary,
+		Description: template.Description,
+		Tags:        append([]string{}, template.Tags...),
+		Status:      models.Draft,
+	}
+	if _, err := s.CreateJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// DuplicateJob creates a Draft copy of an existing job with fresh timestamps and no applicants.
+func (s *JobService) DuplicateJob(id primitive.ObjectID) (*models.Job, error) {
+	source, err := s.GetJobByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	job := *source
+	job.ID = primitive.NilObjectID
+	job.Tags = append([]string{}, source.Tags...)
+	job.Status = models.Draft
+	if _, err := s.CreateJob(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
 }
 
 func (s *JobService) GetJobByID(id primitive.ObjectID) (*models.Job, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	var job models.Job
-	err := s.jobCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	err := s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"_id": id})).Decode(&job)
+	s.canonical(&job)
 	return &job, err
 }
 
-func (s *JobService) GetAllJobs() ([]*models.Job, error) {
+func (s *JobService) GetAllJobs(query *models.JobQuery) ([]*JobListing, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
-	var jobs []*models.Job
-	cursor, err := s.jobCollection.Find(ctx, bson.M{})
+	pipeline, err := s.jobListPipeline(query, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	err = cursor.All(ctx, &jobs)
+	cursor, err := s.jobCollection.Aggregate(ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
 
+	jobs := []*JobListing{}
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	for _, listing := range jobs {
+		s.canonical(&listing.Job)
+	}
+
 	return jobs, nil
 }
 
+// jobListPipeline turns the listing query, plus any extra conditions, into an
+// aggregation. Radius searches must start with $geoNear, which also sorts by and
+// returns the distance.
+func (s *JobService) jobListPipeline(query *models.JobQuery, extra bson.M) (mongo.Pipeline, error) {
+	filter, err := s.buildJobFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	excludeDeleted(filter)
+	if len(extra) > 0 {
+		filter = bson.M{"$and": bson.A{filter, extra}}
+	}
+
+	if query.BBox != "" {
+		box, err := parseBoundingBox(query.BBox)
+		if err != nil {
+			return nil, err
+		}
+		filter["locations.geo"] = bson.M{"$geoWithin": bson.M{"$geometry": box}}
+	}
+
+	point, err := s.searchPoint(query)
+	if err != nil {
+		return nil, err
+	}
+	if point == nil {
+		if query.RadiusKm != 0 {
+			return nil, fmt.Errorf("%w: radius requires near or lat/lng", ErrInvalidJobQuery)
+		}
+		return mongo.Pipeline{{{Key: "$match", Value: filter}}}, nil
+	}
+	if query.BBox != "" {
+		return nil, fmt.Errorf("%w: use either a radius or a bounding box, not both", ErrInvalidJobQuery)
+	}
+
+	radiusKm := query.RadiusKm
+	if radiusKm == 0 {
+		radiusKm = DefaultSearchRadiusKm
+	}
+	if radiusKm < 0 || radiusKm > MaxSearchRadiusKm {
+		return nil, fmt.Errorf("%w: radius must be between 0 and %d km", ErrInvalidJobQuery, MaxSearchRadiusKm)
+	}
+
+	return mongo.Pipeline{{{Key: "$geoNear", Value: bson.M{
+		"near":               point,
+		"key":                "locations.geo",
+		"distanceField":      "distanceKm",
+		"distanceMultiplier": 0.001,
+		"maxDistance":        radiusKm * 1000,
+		"spherical":          true,
+		"query":              filter,
+	}}}}, nil
+}
+
+func (s *JobService) searchPoint(query *models.JobQuery) (*models.GeoPoint, error) {
+	switch {
+	case query.Lat != nil && query.Lng != nil:
+		point := models.NewGeoPoint(*query.Lng, *query.Lat)
+		if !point.Valid() {
+			return nil, fmt.Errorf("%w: lat/lng out of range", ErrInvalidJobQuery)
+		}
+		return point, nil
+	case query.Lat != nil || query.Lng != nil:
+		return nil, fmt.Errorf("%w: lat and lng must be used together", ErrInvalidJobQuery)
+	case query.Near != "":
+		point, ok := s.geocoder.Geocode(query.Near)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown location %q", ErrInvalidJobQuery, query.Near)
+		}
+		return point, nil
+	default:
+		return nil, nil
+	}
+}
+
+// parseBoundingBox reads "minLng,minLat,maxLng,maxLat" into a GeoJSON polygon.
+func parseBoundingBox(value string) (bson.M, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("%w: bbox must be minLng,minLat,maxLng,maxLat", ErrInvalidJobQuery)
+	}
+	var c [4]float64
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bbox must be minLng,minLat,maxLng,maxLat", ErrInvalidJobQuery)
+		}
+		c[i] = v
+	}
+	minLng, minLat, maxLng, maxLat := c[0], c[1], c[2], c[3]
+	if !models.NewGeoPoint(minLng, minLat).Valid() || !models.NewGeoPoint(maxLng, maxLat).Valid() || minLng >= maxLng || minLat >= maxLat {
+		return nil, fmt.Errorf("%w: bbox corners out of range", ErrInvalidJobQuery)
+	}
+
+	return bson.M{
+		"type": "Polygon",
+		"coordinates": [][][]float64{{
+			{minLng, minLat}, {maxLng, minLat}, {maxLng, maxLat}, {minLng, maxLat}, {minLng, minLat},
+		}},
+	}, nil
+}
+
 func (s *JobService) UpdateJob(id string, job *models.Job) (*models.Job, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	objectID, _ := primitive.ObjectIDFromHex(id)
 	job.UpdatedAt = time.Now()
+	if job.Geo != nil && !job.Geo.Valid() {
+		return nil, fmt.Errorf("%w: geo must be a GeoJSON point with [longitude, latitude]", ErrInvalidJob)
+	}
+	normalizeLocations(job)
+	if err := validateLocations(job.Locations); err != nil {
+		return nil, err
+	}
+	if err := normalizeJobLanguages(job); err != nil {
+		return nil, err
+	}
+	s.geocodeJob(job)
+	s.normalizeTags(job)
+	renderDescriptions(job)
+
+	var previous models.Job
+	if err := s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"_id": objectID})).Decode(&previous); err != nil {
+		return nil, err
+	}
+	if len(job.Pipeline) > 0 {
+		pipeline, err := s.changePipeline(ctx, &previous, job.Pipeline)
+		if err != nil {
+			return nil, err
+		}
+		job.Pipeline = pipeline
+	}
 
 	updateData := bson.M{}
 	jobValue := reflect.ValueOf(job).Elem()
@@ -73,24 +548,48 @@ func (s *JobService) UpdateJob(id string, job *models.Job) (*models.Job, error)
 		fieldType := jobType.Field(i)
 
 		if !isZero(fieldValue) {
-			fieldName := fieldType.Tag.Get("bson")
+			fieldName := strings.Split(fieldType.Tag.Get("bson"), ",")[0]
+			if protectedJobFields[fieldName] {
+				continue
+			}
 			if fieldName == "status" {
 				status := fieldValue.Interface().(models.JobStatus)
 				if !isValidJobStatus(status) {
 					return nil, errors.New("invalid job status")
 				}
 			}
-			updateData[fieldType.Tag.Get("bson")] = fieldValue.Interface()
+			updateData[fieldName] = fieldValue.Interface()
 		}
 	}
 
 	updateData["updated_at"] = job.UpdatedAt
+	if job.Description != "" {
+		updateData["descriptionHtml"] = job.DescriptionHTML
+	}
+
+	if err := s.updateSlug(ctx, &previous, updateData); err != nil {
+		return nil, err
+	}
+	if err := s.screenUpdate(ctx, &previous, updateData); err != nil {
+		return nil, err
+	}
+	credits := 0
+	if updateData["status"] == models.Open && previous.Status != models.Open {
+		var err error
+		if credits, err = s.allowPublish(ctx, &previous, previous.PublishedAt == nil); err != nil {
+			return nil, err
+		}
+		if previous.PublishedAt == nil {
+			updateData["publishedAt"] = job.UpdatedAt
+		}
+	}
 
 	filter := bson.M{"_id": objectID}
 	update := bson.M{"$set": updateData}
 
 	_, err := s.jobCollection.UpdateOne(ctx, filter, update)
 	if err != nil {
+		s.refundCredits(previous.Company, credits)
 		return nil, err
 	}
 
@@ -100,6 +599,11 @@ func (s *JobService) UpdateJob(id string, job *models.Job) (*models.Job, error)
 		return nil, err
 	}
 
+	s.canonical(updatedJob)
+	if previous.Status != models.Open {
+		s.published(updatedJob)
+	}
+	revealModeration(updatedJob)
 	return updatedJob, nil
 
 }
@@ -109,7 +613,7 @@ func (s *JobService) GetRecentJobs(limit int64) ([]*models.Job, error) {
 	defer cancel()
 	options := options.Find().SetSort(bson.M{"postedAt": -1}).SetLimit(limit)
 	var jobs []*models.Job
-	cursor, err := s.jobCollection.Find(ctx, bson.M{}, options)
+	cursor, err := s.jobCollection.Find(ctx, excludeDeleted(excludeUnreviewed(bson.M{})), options)
 	if err != nil {
 		return nil, err
 	}
@@ -119,19 +623,14 @@ func (s *JobService) GetRecentJobs(limit int64) ([]*models.Job, error) {
 		return nil, err
 	}
 
+	s.canonical(jobs...)
 	return jobs, nil
 }
 
-func (s *JobService) DeleteJob(id string) error {
-	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
-	defer cancel()
-	objectID, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.M{"_id": objectID}
-	_, err := s.jobCollection.DeleteOne(ctx, filter)
-	return err
-}
-
-func (s *JobService) GetRecommendedJobs(jobID primitive.ObjectID) ([]*models.Job, error) {
+// GetRecommendedJobs ranks Open jobs by similarity to the given job. Candidates are
+// narrowed in Mongo to recent jobs sharing a tag or the job/work type, then scored
+// in memory, which keeps the work bounded for large catalogs.
+func (s *JobService) GetRecommendedJobs(jobID primitive.ObjectID, limit int) ([]*ScoredJob, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 	job, err := s.GetJobByID(jobID)
@@ -139,29 +638,34 @@ func (s *JobService) GetRecommendedJobs(jobID primitive.ObjectID) ([]*models.Job
 		return nil, err
 	}
 
-	filter := bson.M{
-		"tags": bson.M{"$in": job.Tags},
-		"_id":  bson.M{"$ne": job.ID},
+	similar := bson.A{bson.M{"jobType": job.JobType, "workType": job.WorkType}}
+	if len(job.Tags) > 0 {
+		similar = append(similar, bson.M{"tags": bson.M{"$in": job.Tags}})
 	}
-	options := options.Find().SetLimit(5)
-	var recommendedJobs []*models.Job
-	cursor, err := s.jobCollection.Find(ctx, filter, options)
+	filter := excludeDeleted(bson.M{
+		"_id":    bson.M{"$ne": job.ID},
+		"status": models.Open,
+		"$or":    similar,
+	})
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(MaxRecommendationCandidates)
+	cursor, err := s.jobCollection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	err = cursor.All(ctx, &recommendedJobs)
-	if err != nil {
+	var candidates []*models.Job
+	if err = cursor.All(ctx, &candidates); err != nil {
 		return nil, err
 	}
 
-	return recommendedJobs, nil
+	s.canonical(candidates...)
+	return rankJobs(profileFromJob(job), candidates, DefaultRecommendationWeights, clampRecommendationLimit(limit)), nil
 }
 
 func (s *JobService) GetJobsByCompany(companyID primitive.ObjectID) ([]*models.Job, error) {
 	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
-	filter := bson.M{"company": companyID}
+	filter := excludeDeleted(bson.M{"company": companyID})
 	var jobs []*models.Job
 	cursor, err := s.jobCollection.Find(ctx, filter)
 	if err != nil {
@@ -173,9 +677,61 @@ func (s *JobService) GetJobsByCompany(companyID primitive.ObjectID) ([]*models.J
 		return nil, err
 	}
 
+	s.canonical(jobs...)
+	revealModeration(jobs...)
 	return jobs, nil
 }
 
+// protectedJobFields are managed by the service and never taken from an update
+// payload.
+var protectedJobFields = map[string]bool{
+	"_id":             true,
+	"-":               true,
+	"slug":            true,
+	"previousSlugs":   true,
+	"deletedAt":       true,
+	"featured":        true,
+	"publishedAt":     true,
+	"moderation":      true,
+	"descriptionHtml": true,
+	"applicants":      true,
+	"applicantCounts": true,
+}
+
+// updateSlug gives the job a new slug when its title or location changes. The old
+// slug is kept in previousSlugs so existing links keep working.
+func (s *JobService) updateSlug(ctx context.Context, previous *models.Job, updateData bson.M) error {
+	updated := *previous
+	if title, ok := updateData["title"].(string); ok {
+		updated.Title = title
+	}
+	if location, ok := updateData["location"].(string); ok {
+		updated.Location = location
+	}
+	if previous.Slug != "" && updated.Title == previous.Title && updated.Location == previous.Location {
+		return nil
+	}
+
+	companyName, err := s.companyName(ctx, previous.Company)
+	if err != nil {
+		return err
+	}
+	slug, err := s.jobSlug(ctx, &updated, companyName, nil)
+	if err != nil || slug == previous.Slug {
+		return err
+	}
+
+	previousSlugs := []string{}
+	for _, old := range append(previous.PreviousSlugs, previous.Slug) {
+		if old != "" && old != slug {
+			previousSlugs = append(previousSlugs, old)
+		}
+	}
+	updateData["slug"] = slug
+	updateData["previousSlugs"] = previousSlugs
+	return nil
+}
+
 func isZero(v reflect.Value) bool {
 	switch v.Kind() {
 	case reflect.Array, reflect.Slice, reflect.Map:
@@ -195,3 +751,21 @@ func isValidJobStatus(status models.JobStatus) bool {
 		return false
 	}
 }
+
+func isValidJobType(jobType models.JobType) bool {
+	switch jobType {
+	case models.FullTime, models.Contract, models.Temporary, models.Internship, models.Fresher:
+		return true
+	default:
+		return false
+	}
+}
+
+func isValidWorkType(workType models.WorkType) bool {
+	switch workType {
+	case models.Remote, models.HybridWork, models.OnSite:
+		return true
+	default:
+		return false
+	}
+}
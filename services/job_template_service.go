@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrJobTemplateNotFound = errors.New("job template not found")
+
+type JobTemplateService struct {
+	templateCollection *mongo.Collection
+}
+
+func NewJobTemplateService(db *mongo.Client, collection string) *JobTemplateService {
+	return &JobTemplateService{
+		templateCollection: db.Database("jobsy-api").Collection(collection),
+	}
+}
+
+func (s *JobTemplateService) CreateTemplate(template *models.JobTemplate) (*models.JobTemplate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if err := validateJobTemplate(template); err != nil {
+		return nil, err
+	}
+	template.ID = primitive.NewObjectID()
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = time.Now()
+	if _, err := s.templateCollection.InsertOne(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (s *JobTemplateService) GetTemplatesByCompany(companyID primitive.ObjectID) ([]*models.JobTemplate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"name": 1})
+	cursor, err := s.templateCollection.Find(ctx, bson.M{"company": companyID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := []*models.JobTemplate{}
+	if err = cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetTemplateByID only returns the template when it belongs to the given company.
+func (s *JobTemplateService) GetTemplateByID(id, companyID primitive.ObjectID) (*models.JobTemplate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var template models.JobTemplate
+	err := s.templateCollection.FindOne(ctx, bson.M{"_id": id, "company": companyID}).Decode(&template)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (s *JobTemplateService) UpdateTemplate(id, companyID primitive.ObjectID, template *models.JobTemplate) (*models.JobTemplate, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if err := validateJobTemplate(template); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{
+		"name":        template.Name,
+		"title":       template.Title,
+		"location":    template.Location,
+		"jobType":     template.JobType,
+		"workType":    template.WorkType,
+		"salary":      template.Salary,
+		"summary":     template.Summary,
+		"description": template.Description,
+		"tags":        template.Tags,
+		"updatedAt":   time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.JobTemplate
+	err := s.templateCollection.FindOneAndUpdate(ctx, bson.M{"_id": id, "company": companyID}, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *JobTemplateService) DeleteTemplate(id, companyID primitive.ObjectID) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	result, err := s.templateCollection.DeleteOne(ctx, bson.M{"_id": id, "company": companyID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrJobTemplateNotFound
+	}
+	return nil
+}
+
+func validateJobTemplate(template *models.JobTemplate) error {
+	if template.Name == "" {
+		return errors.New("template name is required")
+	}
+	if template.Title == "" {
+		return errors.New("job title is required")
+	}
+	return nil
+}
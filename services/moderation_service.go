@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsy-api/config"
+	"jobsy-api/models"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// duplicateWindow and maxDuplicateCandidates bound the jobs a new posting is
+	// compared with when looking for copied text.
+	duplicateWindow        = 30 * 24 * time.Hour
+	maxDuplicateCandidates = 500
+	// minDuplicateWords keeps short postings from matching each other by accident.
+	minDuplicateWords = 30
+)
+
+var (
+	ErrInvalidModeration = errors.New("invalid moderation decision")
+	ErrCompanyNotFound   = errors.New("company not found")
+)
+
+var defaultSuspiciousKeywords = []string{
+	"whatsapp", "telegram", "guaranteed income", "earn money fast", "easy money",
+	"daily payout", "no interview", "limited slots", "work from home and earn",
+}
+
+var defaultPaymentTerms = []string{
+	"registration fee", "training fee", "processing fee", "application fee",
+	"security deposit", "refundable deposit", "upfront payment", "starter kit",
+	"western union", "moneygram", "wire transfer", "gift card", "bitcoin",
+	"crypto wallet", "bank details",
+}
+
+// ModerationRules are the heuristic checks run on jobs about to go live. Phrases
+// match whole words, case insensitively.
+type ModerationRules struct {
+	SuspiciousKeywords []string
+	PaymentTerms       []string
+	// DuplicateSimilarity flags jobs whose text is at least this similar to a
+	// recent job of another company. 0 turns the check off.
+	DuplicateSimilarity float64
+}
+
+// ModerationRulesFromConfig is the default rule set extended by the environment.
+func ModerationRulesFromConfig() ModerationRules {
+	return ModerationRules{
+		SuspiciousKeywords:  append(append([]string{}, defaultSuspiciousKeywords...), config.ModerationKeywords()...),
+		PaymentTerms:        append(append([]string{}, defaultPaymentTerms...), config.ModerationPaymentTerms()...),
+		DuplicateSimilarity: config.ModerationDuplicateSimilarity(),
+	}
+}
+
+// ModerationService holds jobs from unverified companies, and jobs that trip one
+// of the rules, for an admin to approve or reject. Owners are notified of the
+// decision.
+type ModerationService struct {
+	jobCollection     *mongo.Collection
+	companyCollection *mongo.Collection
+	userCollection    *mongo.Collection
+	jobService        *JobService
+	notifier          Notifier
+	rules             ModerationRules
+}
+
+func NewModerationService(db *mongo.Client, jobCollectionName, companyCollectionName, userCollectionName string, jobService *JobService, notifier Notifier) *ModerationService {
+	return &ModerationService{
+		jobCollection:     db.Database("jobsy-api").Collection(jobCollectionName),
+		companyCollection: db.Database("jobsy-api").Collection(companyCollectionName),
+		userCollection:    db.Database("jobsy-api").Collection(userCollectionName),
+		jobService:        jobService,
+		notifier:          notifier,
+		rules:             ModerationRulesFromConfig(),
+	}
+}
+
+func (s *ModerationService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.jobCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "moderation.submittedAt", Value: 1}},
+	})
+	return err
+}
+
+// Screen implements JobModerator.
+func (s *ModerationService) Screen(ctx context.Context, jobs ...*models.Job) error {
+	verified, err := s.verifiedCompanies(ctx, jobs)
+	if err != nil {
+		return err
+	}
+	candidates, err := s.duplicateCandidates(ctx, jobs)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, job := range jobs {
+		var flags []string
+		if !verified[job.Company] {
+			flags = append(flags, "company is not verified")
+		}
+		if job.Moderation != nil && job.Moderation.Decision == models.ModerationRejected {
+			flags = append(flags, "previously rejected: "+job.Moderation.Reason)
+		}
+		flags = append(flags, s.textFlags(job)...)
+		if duplicate := s.findDuplicate(job, candidates); duplicate != nil {
+			flags = append(flags, "duplicates job "+duplicate.ID.Hex()+" of another company")
+		}
+
+		if len(flags) == 0 {
+			if job.Moderation == nil {
+				job.Moderation = &models.JobModeration{RequestedStatus: job.Status, SubmittedAt: now}
+			} else {
+				moderation := *job.Moderation
+				job.Moderation = &moderation
+			}
+			job.Moderation.ReviewedText = jobText(job)
+			continue
+		}
+		job.Moderation = &models.JobModeration{RequestedStatus: job.Status, Flags: flags, SubmittedAt: now, ReviewedText: jobText(job)}
+		job.Status = models.PendingReview
+	}
+	return nil
+}
+
+// ScreenText implements JobModerator. The job keeps the text the reviewed version
+// was screened with, so a run of small edits is still measured against it.
+func (s *ModerationService) ScreenText(ctx context.Context, job *models.Job) error {
+	flags := s.textFlags(job)
+	if len(flags) == 0 {
+		return nil
+	}
+	if job.Status == models.PendingReview && job.Moderation != nil {
+		moderation := *job.Moderation
+		for _, flag := range flags {
+			if !slices.Contains(moderation.Flags, flag) {
+				moderation.Flags = append(moderation.Flags, flag)
+			}
+		}
+		job.Moderation = &moderation
+		return nil
+	}
+	moderation := &models.JobModeration{RequestedStatus: job.Status, Flags: flags, SubmittedAt: time.Now()}
+	if job.Moderation != nil {
+		moderation.ReviewedText = job.Moderation.ReviewedText
+	}
+	job.Moderation = moderation
+	job.Status = models.PendingReview
+	return nil
+}
+
+// textFlags runs the keyword and payment rules over the job's text.
+func (s *ModerationService) textFlags(job *models.Job) []string {
+	var flags []string
+	text := " " + strings.Join(textWords(jobText(job)), " ") + " "
+	for _, keyword := range s.rules.SuspiciousKeywords {
+		if containsPhrase(text, keyword) {
+			flags = append(flags, "suspicious keyword: "+keyword)
+		}
+	}
+	for _, term := range s.rules.PaymentTerms {
+		if containsPhrase(text, term) {
+			flags = append(flags, "asks for payment: "+term)
+		}
+	}
+	return flags
+}
+
+// containsPhrase reports whether the space padded, normalized text contains the
+// phrase as whole words.
+func containsPhrase(text, phrase string) bool {
+	words := textWords(phrase)
+	if len(words) == 0 {
+		return false
+	}
+	return strings.Contains(text, " "+strings.Join(words, " ")+" ")
+}
+
+func (s *ModerationService) verifiedCompanies(ctx context.Context, jobs []*models.Job) (map[primitive.ObjectID]bool, error) {
+	var userIDs []primitive.ObjectID
+	for _, job := range jobs {
+		userIDs = append(userIDs, job.Company)
+	}
+	cursor, err := s.companyCollection.Find(ctx, bson.M{"userId": bson.M{"$in": userIDs}, "verified": true})
+	if err != nil {
+		return nil, err
+	}
+	var companies []*models.Company
+	if err = cursor.All(ctx, &companies); err != nil {
+		return nil, err
+	}
+	verified := make(map[primitive.ObjectID]bool, len(companies))
+	for _, company := range companies {
+		verified[company.UserId] = true
+	}
+	return verified, nil
+}
+
+type duplicateCandidate struct {
+	ID       primitive.ObjectID
+	Company  primitive.ObjectID
+	shingles map[string]bool
+}
+
+// duplicateCandidates loads the recent live or pending jobs that the screened jobs
+// are compared with.
+func (s *ModerationService) duplicateCandidates(ctx context.Context, jobs []*models.Job) ([]duplicateCandidate, error) {
+	if s.rules.DuplicateSimilarity <= 0 {
+		return nil, nil
+	}
+	var screened []primitive.ObjectID
+	for _, job := range jobs {
+		screened = append(screened, job.ID)
+	}
+	filter := excludeDeleted(bson.M{
+		"_id":       bson.M{"$nin": screened},
+		"status":    bson.M{"$in": bson.A{models.Open, models.PendingReview}},
+		"createdAt": bson.M{"$gte": time.Now().Add(-duplicateWindow)},
+	})
+	opts := options.Find().
+		SetSort(bson.M{"createdAt": -1}).
+		SetLimit(maxDuplicateCandidates).
+		SetProjection(bson.M{"company": 1, "title": 1, "summary": 1, "description": 1, "translations": 1})
+	cursor, err := s.jobCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var recent []*models.Job
+	if err = cursor.All(ctx, &recent); err != nil {
+		return nil, err
+	}
+
+	candidates := make([]duplicateCandidate, 0, len(recent))
+	for _, job := range recent {
+		if len(textWords(jobText(job))) < minDuplicateWords {
+			continue
+		}
+		candidates = append(candidates, duplicateCandidate{ID: job.ID, Company: job.Company, shingles: textShingles(jobText(job))})
+	}
+	return candidates, nil
+}
+
+// findDuplicate returns a job of another company with nearly the same text. Jobs
+// of the same company are not compared, since reusing one's own text is normal.
+func (s *ModerationService) findDuplicate(job *models.Job, candidates []duplicateCandidate) *duplicateCandidate {
+	text := jobText(job)
+	if len(candidates) == 0 || len(textWords(text)) < minDuplicateWords {
+		return nil
+	}
+	shingles := textShingles(text)
+	for i, candidate := range candidates {
+		if candidate.Company == job.Company {
+			continue
+		}
+		if shingleSimilarity(shingles, candidate.shingles) >= s.rules.DuplicateSimilarity {
+			return &candidates[i]
+		}
+	}
+	return nil
+}
+
+func (s *ModerationService) GetQueue() ([]*models.Job, error) {
+	return s.jobService.GetModerationQueue()
+}
+
+// ApproveJob puts a pending job live and tells the owner.
+func (s *ModerationService) ApproveJob(id, reviewerID primitive.ObjectID) (*models.Job, error) {
+	job, err := s.jobService.decideModeration(id, reviewerID, models.ModerationApproved, "")
+	if err != nil {
+		return nil, err
+	}
+	body := fmt.Sprintf("Your job %q was approved and is now %s.", job.Title, strings.ToLower(string(job.Status)))
+	if job.Status == models.Open {
+		body += "\n" + job.CanonicalURL
+	}
+	s.notifyOwner(job, "Your job was approved: "+job.Title, body)
+	return job, nil
+}
+
+// RejectJob rejects a pending job with a reason that is passed on to the owner.
+func (s *ModerationService) RejectJob(id, reviewerID primitive.ObjectID, reason string) (*models.Job, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidModeration)
+	}
+	job, err := s.jobService.decideModeration(id, reviewerID, models.ModerationRejected, reason)
+	if err != nil {
+		return nil, err
+	}
+	body := fmt.Sprintf("Your job %q was not approved.\nReason: %s\nYou can edit the job and publish it again for another review.", job.Title, reason)
+	s.notifyOwner(job, "Your job was not approved: "+job.Title, body)
+	return job, nil
+}
+
+// notifyOwner never fails the decision; a lost notification is only logged.
+func (s *ModerationService) notifyOwner(job *models.Job, subject, body string) {
+	var ctx, cancel = context.WithTimeout(context.Background(), alertNotifyTimeout)
+	defer cancel()
+	var owner models.User
+	if err := s.userCollection.FindOne(ctx, bson.M{"_id": job.Company}).Decode(&owner); err != nil {
+		log.Printf("moderation: no owner for job %s: %v", job.ID.Hex(), err)
+		return
+	}
+	err := s.notifier.Notify(ctx, Notification{UserID: owner.ID, To: owner.Email, Subject: subject, Body: body})
+	if err != nil {
+		log.Printf("moderation: notifying %s about job %s: %v", owner.ID.Hex(), job.ID.Hex(), err)
+	}
+}
+
+// SetCompanyVerified marks the company owned by the given user as verified, so its
+// jobs only go to review when a rule flags them.
+func (s *ModerationService) SetCompanyVerified(userID primitive.ObjectID, verified bool) (*models.Company, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	update := bson.M{"$set": bson.M{"verified": verified, "updatedAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var company models.Company
+	err := s.companyCollection.FindOneAndUpdate(ctx, bson.M{"userId": userID}, update, opts).Decode(&company)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCompanyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &company, nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Notification struct {
+	UserID  primitive.ObjectID
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users. Swap LogNotifier for an email or push
+// implementation without touching the services that send notifications.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// LogNotifier writes notifications to the server log. It is the default until a
+// mail provider is configured.
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification Notification) error {
+	log.Printf("notify %s <%s>: %s\n%s", notification.UserID.Hex(), notification.To, notification.Subject, notification.Body)
+	return nil
+}
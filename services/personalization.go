@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"jobsy-api/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxHistoryApplications = 50
+
+// PersonalizedJob is a recommendation for a logged-in user together with the
+// human-readable reasons it was picked.
+type PersonalizedJob struct {
+	*ScoredJob
+	Reasons []string `json:"reasons"`
+}
+
+// applicantHistory is what we know about a user from the applications they sent.
+type applicantHistory struct {
+	applications []*models.Applicant
+	appliedJobs  []*models.Job
+	appliedIDs   []primitive.ObjectID
+}
+
+// GetJobsForApplicant ranks Open jobs for a user from the jobs they applied to and
+// the profile details on their applications. Jobs they already applied to are left
+// out. Users without history get the newest Open jobs.
+func (s *JobService) GetJobsForApplicant(userID primitive.ObjectID, limit int) ([]*PersonalizedJob, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	limit = clampRecommendationLimit(limit)
+
+	history, err := s.applicantHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	profile := history.profile()
+
+	filter := excludeDeleted(bson.M{
+		"status": models.Open,
+		"_id":    bson.M{"$nin": history.appliedIDs},
+	})
+	if len(history.appliedJobs) > 0 {
+		similar := bson.A{}
+		if tags := history.tagList(); len(tags) > 0 {
+			similar = append(similar, bson.M{"tags": bson.M{"$in": tags}})
+		}
+		if jobTypes := profile.jobTypeList(); len(jobTypes) > 0 {
+			similar = append(similar, bson.M{"jobType": bson.M{"$in": jobTypes}})
+		}
+		if len(similar) > 0 {
+			filter["$or"] = similar
+		}
+	}
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(MaxRecommendationCandidates)
+	cursor, err := s.jobCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*models.Job
+	if err = cursor.All(ctx, &candidates); err != nil {
+		return nil, err
+	}
+
+	s.canonical(candidates...)
+	results := []*PersonalizedJob{}
+	if len(profile.terms) == 0 && len(profile.tags) == 0 {
+		for _, job := range candidates[:min(limit, len(candidates))] {
+			results = append(results, &PersonalizedJob{
+				ScoredJob: &ScoredJob{Job: job},
+				Reasons:   []string{"Recently posted on Jobsy"},
+			})
+		}
+		return results, nil
+	}
+
+	for _, scored := range rankJobs(profile, candidates, DefaultRecommendationWeights, limit) {
+		results = append(results, &PersonalizedJob{ScoredJob: scored, Reasons: history.explain(profile, scored)})
+	}
+	return results, nil
+}
+
+func (s *JobService) applicantHistory(ctx context.Context, userID primitive.ObjectID) (*applicantHistory, error) {
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(maxHistoryApplications)
+	cursor, err := s.applicantCollection.Find(ctx, bson.M{"applicantId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &applicantHistory{appliedIDs: []primitive.ObjectID{}}
+	if err = cursor.All(ctx, &history.applications); err != nil {
+		return nil, err
+	}
+	for _, application := range history.applications {
+		history.appliedIDs = append(history.appliedIDs, application.JobID)
+	}
+	if len(history.appliedIDs) == 0 {
+		return history, nil
+	}
+
+	cursor, err = s.jobCollection.Find(ctx, excludeDeleted(bson.M{"_id": bson.M{"$in": history.appliedIDs}}))
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*models.Job
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	// Keep the jobs in application order so recent applications weigh more.
+	byID := make(map[primitive.ObjectID]*models.Job, len(jobs))
+	for _, job := range jobs {
+		byID[job.ID] = job
+	}
+	for _, id := range history.appliedIDs {
+		if job, ok := byID[id]; ok {
+			history.appliedJobs = append(history.appliedJobs, job)
+		}
+	}
+	return history, nil
+}
+
+// profile blends the applied jobs, most recent first with decaying weight, and
+// the title and summary the user gave on their latest application.
+func (h *applicantHistory) profile() *jobProfile {
+	profile := newJobProfile()
+	for i, job := range h.appliedJobs {
+		profile.add(job, 1/(1+0.2*float64(i)))
+	}
+
+	if len(h.applications) > 0 {
+		latest := h.applications[0]
+		for _, token := range tokenize(latest.CurrentJobTitle) {
+			profile.terms[token] += 2
+		}
+		for _, token := range tokenize(latest.Summary) {
+			profile.terms[token] += 0.5
+		}
+		if profile.salary == 0 {
+			if desired, ok := parseSalaryAmount(latest.DesiredSalary); ok {
+				profile.salary = desired
+			}
+		}
+	}
+	return profile
+}
+
+// tagList is the tags of the applied jobs as they are spelled on the jobs. The
+// profile only keeps lowercased tag keys, which an exact $in on tags would miss.
+func (h *applicantHistory) tagList() []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, job := range h.appliedJobs {
+		for _, tag := range job.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+func (p *jobProfile) jobTypeList() []models.JobType {
+	jobTypes := make([]models.JobType, 0, len(p.jobTypes))
+	for jobType := range p.jobTypes {
+		jobTypes = append(jobTypes, jobType)
+	}
+	return jobTypes
+}
+
+// explain turns the strongest parts of a score into reasons the user can read.
+func (h *applicantHistory) explain(profile *jobProfile, scored *ScoredJob) []string {
+	job, breakdown := scored.Job, scored.Breakdown
+	reasons := []string{}
+
+	var shared []string
+	for _, tag := range job.Tags {
+		if _, ok := profile.tags[tagKey(tag)]; ok {
+			shared = append(shared, tag)
+		}
+	}
+	if len(shared) > 0 {
+		reasons = append(reasons, "Matches skills from jobs you applied to: "+strings.Join(shared[:min(3, len(shared))], ", "))
+	}
+
+	if breakdown.Text != nil && *breakdown.Text >= 0.2 {
+		if similar := h.mostSimilarApplied(job); similar != nil {
+			reasons = append(reasons, fmt.Sprintf("Similar to %q, which you applied to", similar.Title))
+		}
+	}
+	if len(h.applications) > 0 && h.applications[0].CurrentJobTitle != "" && sharesTitleWords(job.Title, h.applications[0].CurrentJobTitle) {
+		reasons = append(reasons, fmt.Sprintf("Fits your current title %q", h.applications[0].CurrentJobTitle))
+	}
+	if breakdown.Location != nil && *breakdown.Location >= 0.5 && job.Location != "" {
+		reasons = append(reasons, "In or near "+job.Location+", where you have applied before")
+	}
+	if breakdown.JobType != nil && *breakdown.JobType >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("%s role, like most of your applications", job.JobType))
+	}
+	if breakdown.WorkType != nil && *breakdown.WorkType >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("%s, like your previous applications", job.WorkType))
+	}
+	if breakdown.Salary != nil && *breakdown.Salary >= 0.8 {
+		reasons = append(reasons, "Salary in line with your expectations")
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "Related to jobs you applied to")
+	}
+	return reasons
+}
+
+func (h *applicantHistory) mostSimilarApplied(job *models.Job) *models.Job {
+	var best *models.Job
+	bestScore := 0.0
+	target := profileFromJob(job).terms
+	for _, applied := range h.appliedJobs {
+		if score := cosine(target, profileFromJob(applied).terms); score > bestScore {
+			best, bestScore = applied, score
+		}
+	}
+	return best
+}
+
+func sharesTitleWords(a, b string) bool {
+	words := map[string]bool{}
+	for _, token := range tokenize(a) {
+		words[token] = true
+	}
+	for _, token := range tokenize(b) {
+		if words[token] {
+			return true
+		}
+	}
+	return false
+}
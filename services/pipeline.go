@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsy-api/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxPipelineStages = 20
+
+var ErrInvalidPipeline = errors.New("invalid pipeline")
+
+func isValidStageType(stageType models.StageType) bool {
+	switch stageType {
+	case models.StageScreening, models.StageInterview, models.StageOffer, models.StageHired, models.StageRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// normalizePipeline checks the stages of a pipeline and tidies their names. Every
+// pipeline starts with Pending, where new applications land, and it is added when
+// missing. Withdrawn is kept for candidates. Stage names become counter keys on
+// the job, so they cannot contain "." or start with "$".
+func normalizePipeline(stages []models.PipelineStage) ([]models.PipelineStage, error) {
+	pipeline := []models.PipelineStage{}
+	seen := map[string]bool{}
+	hired, rejected := false, false
+	for i, stage := range stages {
+		stage.Name = models.ApplicationStatus(strings.Join(strings.Fields(string(stage.Name)), " "))
+		name := string(stage.Name)
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("%w: stage %d has no name", ErrInvalidPipeline, i+1)
+		case strings.Contains(name, ".") || strings.HasPrefix(name, "$"):
+			return nil, fmt.Errorf("%w: stage %q cannot contain \".\" or start with \"$\"", ErrInvalidPipeline, name)
+		case strings.EqualFold(name, string(models.Withdrawn)):
+			return nil, fmt.Errorf("%w: %q is reserved for candidates", ErrInvalidPipeline, models.Withdrawn)
+		case !isValidStageType(stage.Type):
+			return nil, fmt.Errorf("%w: invalid type %q for stage %q", ErrInvalidPipeline, stage.Type, name)
+		case seen[strings.ToLower(name)]:
+			return nil, fmt.Errorf("%w: stage %q appears twice", ErrInvalidPipeline, name)
+		}
+		if strings.EqualFold(name, string(models.Pending)) {
+			if i != 0 || stage.Type != models.StageScreening {
+				return nil, fmt.Errorf("%w: %q must be the first stage, of type %q", ErrInvalidPipeline, models.Pending, models.StageScreening)
+			}
+			stage.Name = models.Pending
+		}
+		seen[strings.ToLower(name)] = true
+		hired = hired || stage.Type == models.StageHired
+		rejected = rejected || stage.Type == models.StageRejected
+		pipeline = append(pipeline, stage)
+	}
+	if !seen[strings.ToLower(string(models.Pending))] {
+		pipeline = append([]models.PipelineStage{{Name: models.Pending, Type: models.StageScreening}}, pipeline...)
+	}
+	if !hired || !rejected {
+		return nil, fmt.Errorf("%w: a pipeline needs a %q and a %q stage", ErrInvalidPipeline, models.StageHired, models.StageRejected)
+	}
+	if len(pipeline) > maxPipelineStages {
+		return nil, fmt.Errorf("%w: at most %d stages are allowed", ErrInvalidPipeline, maxPipelineStages)
+	}
+	return pipeline, nil
+}
+
+// jobPipeline is the pipeline of a job; jobs created before pipelines existed use
+// the default one.
+func jobPipeline(job *models.Job) []models.PipelineStage {
+	if len(job.Pipeline) == 0 {
+		return models.DefaultPipeline()
+	}
+	return job.Pipeline
+}
+
+func pipelineStage(pipeline []models.PipelineStage, name models.ApplicationStatus) (models.PipelineStage, bool) {
+	for _, stage := range pipeline {
+		if stage.Name == name {
+			return stage, true
+		}
+	}
+	return models.PipelineStage{}, false
+}
+
+// checkStageMove returns the stage an application may move to from its current
+// status. Applications never go back to Pending, and where the pipeline has an
+// offer stage, nobody is hired without an offer.
+func checkStageMove(pipeline []models.PipelineStage, from, to models.ApplicationStatus) (models.PipelineStage, error) {
+	target, ok := pipelineStage(pipeline, to)
+	if !ok {
+		return target, fmt.Errorf("%w: %q is not a stage of the job's pipeline", ErrInvalidApplication, to)
+	}
+	if from == to {
+		return target, nil
+	}
+	if to == models.Pending {
+		return target, fmt.Errorf("%w: applications cannot go back to %q", ErrInvalidApplication, models.Pending)
+	}
+	if target.Type == models.StageHired {
+		current, _ := pipelineStage(pipeline, from)
+		if current.Type != models.StageOffer && hasStageType(pipeline, models.StageOffer) {
+			return target, fmt.Errorf("%w: make an offer before hiring", ErrInvalidApplication)
+		}
+	}
+	return target, nil
+}
+
+func hasStageType(pipeline []models.PipelineStage, stageType models.StageType) bool {
+	for _, stage := range pipeline {
+		if stage.Type == stageType {
+			return true
+		}
+	}
+	return false
+}
+
+// companyPipeline is the default pipeline of the company owned by the user, or
+// DefaultPipeline when it has none.
+func (s *JobService) companyPipeline(ctx context.Context, userID primitive.ObjectID) ([]models.PipelineStage, error) {
+	var company models.Company
+	opts := options.FindOne().SetProjection(bson.M{"pipeline": 1})
+	err := s.companyCollection.FindOne(ctx, bson.M{"userId": userID}, opts).Decode(&company)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.DefaultPipeline(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(company.Pipeline) == 0 {
+		return models.DefaultPipeline(), nil
+	}
+	return company.Pipeline, nil
+}
+
+// applyPipeline checks the pipeline sent with a new job, or gives the job a copy
+// of fallback when none was sent. The fallback is checked too, since companies
+// stored before pipelines were validated may hold any.
+func applyPipeline(job *models.Job, fallback []models.PipelineStage) error {
+	stages := job.Pipeline
+	if len(stages) == 0 {
+		stages = fallback
+	}
+	pipeline, err := normalizePipeline(stages)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+	job.Pipeline = pipeline
+	return nil
+}
+
+// changePipeline checks a new pipeline for an existing job. A stage can only be
+// removed or renamed once no application is in it. The applications are asked
+// rather than the job's counters, which miss those from before the counters.
+func (s *JobService) changePipeline(ctx context.Context, previous *models.Job, stages []models.PipelineStage) ([]models.PipelineStage, error) {
+	pipeline, err := normalizePipeline(stages)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJob, err)
+	}
+	statuses, err := s.applicantCollection.Distinct(ctx, "status", bson.M{"jobId": previous.ID})
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range statuses {
+		status, _ := value.(string)
+		if models.ApplicationStatus(status) == models.Withdrawn {
+			continue
+		}
+		if _, ok := pipelineStage(pipeline, models.ApplicationStatus(status)); !ok {
+			return nil, fmt.Errorf("%w: stage %q still has applications", ErrInvalidJob, status)
+		}
+	}
+	return pipeline, nil
+}
+
+// SetDefaultPipeline sets the pipeline new jobs of the user's company start with.
+// Existing jobs keep theirs.
+func (s *CompanyService) SetDefaultPipeline(userID primitive.ObjectID, stages []models.PipelineStage) (*models.Company, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	pipeline, err := normalizePipeline(stages)
+	if err != nil {
+		return nil, err
+	}
+	update := bson.M{"$set": bson.M{"pipeline": pipeline, "updatedAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var company models.Company
+	err = s.companyCollection.FindOneAndUpdate(ctx, bson.M{"userId": userID}, update, opts).Decode(&company)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrCompanyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &company, nil
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsy-api/models"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrQuotaExceeded = errors.New("posting quota exceeded")
+	ErrUnknownPlan   = errors.New("unknown plan")
+)
+
+// PublishQuota is consulted by JobService before a job becomes Open or featured.
+type PublishQuota interface {
+	// AllowPublish checks the open job and monthly posting limits for a job that is
+	// about to become Open. firstPublish is false when a closed job is reopened,
+	// which does not count as a new posting. It returns the credits it spent.
+	AllowPublish(ctx context.Context, job *models.Job, firstPublish bool) (int, error)
+	AllowFeatured(ctx context.Context, job *models.Job) error
+	// ReservePostings returns how many of n new Open jobs the company may publish
+	// right now, and the credits spent for those beyond the monthly allowance.
+	ReservePostings(ctx context.Context, companyID primitive.ObjectID, n int) (int, int, error)
+	// RefundCredits gives back credits spent on postings that were never saved.
+	RefundCredits(ctx context.Context, companyID primitive.ObjectID, credits int) error
+}
+
+// PlanUsage is a company's plan and how much of it is used in the current month.
+type PlanUsage struct {
+	Plan              models.Plan `json:"plan"`
+	Credits           int         `json:"credits"`
+	OpenJobs          int         `json:"openJobs"`
+	PostingsThisMonth int         `json:"postingsThisMonth"`
+	FeaturedJobs      int         `json:"featuredJobs"`
+	PeriodStart       time.Time   `json:"periodStart"`
+	PeriodEnd         time.Time   `json:"periodEnd"`
+}
+
+// QuotaService keeps company subscriptions and enforces their plan limits. The
+// checks count jobs at the time of the request, so two postings racing for the
+// last slot can both get through; credits, however, are spent atomically.
+type QuotaService struct {
+	subscriptionCollection *mongo.Collection
+	jobCollection          *mongo.Collection
+}
+
+func NewQuotaService(db *mongo.Client, subscriptionCollectionName, jobCollectionName string) *QuotaService {
+	return &QuotaService{
+		subscriptionCollection: db.Database("jobsy-api").Collection(subscriptionCollectionName),
+		jobCollection:          db.Database("jobsy-api").Collection(jobCollectionName),
+	}
+}
+
+func (s *QuotaService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.subscriptionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "company", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// ListPlans returns the available plans from the smallest to the largest.
+func (s *QuotaService) ListPlans() []models.Plan {
+	plans := make([]models.Plan, 0, len(models.Plans))
+	for _, plan := range models.Plans {
+		plans = append(plans, plan)
+	}
+	size := func(plan models.Plan) int {
+		if plan.MaxOpenJobs == models.Unlimited {
+			return math.MaxInt
+		}
+		return plan.MaxOpenJobs
+	}
+	sort.Slice(plans, func(i, j int) bool { return size(plans[i]) < size(plans[j]) })
+	return plans
+}
+
+// GetSubscription returns the company's subscription, or the default plan without
+// credits when it never had one.
+func (s *QuotaService) GetSubscription(companyID primitive.ObjectID) (*models.Subscription, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	return s.subscription(ctx, companyID)
+}
+
+func (s *QuotaService) subscription(ctx context.Context, companyID primitive.ObjectID) (*models.Subscription, error) {
+	var subscription models.Subscription
+	err := s.subscriptionCollection.FindOne(ctx, bson.M{"company": companyID}).Decode(&subscription)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &models.Subscription{Company: companyID, Plan: models.DefaultPlan}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// SetPlan moves the company to another plan, keeping its credits.
+func (s *QuotaService) SetPlan(companyID primitive.ObjectID, plan string) (*models.Subscription, error) {
+	if _, ok := models.Plans[plan]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPlan, plan)
+	}
+	return s.upsertSubscription(companyID, bson.M{"$set": bson.M{"plan": plan}})
+}
+
+// GrantCredits adds (or with a negative amount removes) posting credits.
+func (s *QuotaService) GrantCredits(companyID primitive.ObjectID, credits int) (*models.Subscription, error) {
+	return s.upsertSubscription(companyID, bson.M{"$inc": bson.M{"credits": credits}})
+}
+
+func (s *QuotaService) upsertSubscription(companyID primitive.ObjectID, update bson.M) (*models.Subscription, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	now := time.Now()
+	if _, ok := update["$set"]; !ok {
+		update["$set"] = bson.M{}
+	}
+	update["$set"].(bson.M)["updatedAt"] = now
+	onInsert := bson.M{"_id": primitive.NewObjectID(), "createdAt": now}
+	if _, ok := update["$set"].(bson.M)["plan"]; !ok {
+		onInsert["plan"] = models.DefaultPlan
+	}
+	if _, ok := update["$inc"]; !ok {
+		onInsert["credits"] = 0
+	}
+	update["$setOnInsert"] = onInsert
+
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var subscription models.Subscription
+	err := s.subscriptionCollection.FindOneAndUpdate(ctx, bson.M{"company": companyID}, update, opts).Decode(&subscription)
+	if err != nil {
+		return nil, err
+	}
+	if subscription.Credits < 0 {
+		// Never leave a negative balance behind.
+		_, err = s.subscriptionCollection.UpdateOne(ctx, bson.M{"_id": subscription.ID}, bson.M{"$set": bson.M{"credits": 0}})
+		subscription.Credits = 0
+	}
+	return &subscription, err
+}
+
+func (s *QuotaService) GetUsage(companyID primitive.ObjectID) (*PlanUsage, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	subscription, err := s.subscription(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	start, end := billingPeriod(time.Now())
+	usage := &PlanUsage{
+		Plan:        planFor(subscription),
+		Credits:     subscription.Credits,
+		PeriodStart: start,
+		PeriodEnd:   end,
+	}
+	if usage.OpenJobs, err = s.countOpen(ctx, companyID, primitive.NilObjectID, false); err != nil {
+		return nil, err
+	}
+	if usage.FeaturedJobs, err = s.countOpen(ctx, companyID, primitive.NilObjectID, true); err != nil {
+		return nil, err
+	}
+	if usage.PostingsThisMonth, err = s.countPostings(ctx, companyID, primitive.NilObjectID, start); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+func (s *QuotaService) AllowPublish(ctx context.Context, job *models.Job, firstPublish bool) (int, error) {
+	subscription, err := s.subscription(ctx, job.Company)
+	if err != nil {
+		return 0, err
+	}
+	plan := planFor(subscription)
+
+	if plan.MaxOpenJobs != models.Unlimited {
+		open, err := s.countOpen(ctx, job.Company, job.ID, false)
+		if err != nil {
+			return 0, err
+		}
+		if open >= plan.MaxOpenJobs {
+			return 0, fmt.Errorf("%w: the %s plan allows %d open jobs at a time", ErrQuotaExceeded, plan.Name, plan.MaxOpenJobs)
+		}
+	}
+	if job.Featured {
+		if err := s.allowFeatured(ctx, job, plan); err != nil {
+			return 0, err
+		}
+	}
+	if !firstPublish || plan.MonthlyPostings == models.Unlimited {
+		return 0, nil
+	}
+
+	start, _ := billingPeriod(time.Now())
+	posted, err := s.countPostings(ctx, job.Company, job.ID, start)
+	if err != nil {
+		return 0, err
+	}
+	if posted < plan.MonthlyPostings {
+		return 0, nil
+	}
+	// Over the monthly allowance: spend a credit if there is one left.
+	filter := bson.M{"company": job.Company, "credits": bson.M{"$gt": 0}}
+	update := bson.M{"$inc": bson.M{"credits": -1}, "$set": bson.M{"updatedAt": time.Now()}}
+	result, err := s.subscriptionCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	if result.ModifiedCount == 0 {
+		return 0, fmt.Errorf("%w: the %s plan allows %d postings per month and no credits are left", ErrQuotaExceeded, plan.Name, plan.MonthlyPostings)
+	}
+	return 1, nil
+}
+
+func (s *QuotaService) ReservePostings(ctx context.Context, companyID primitive.ObjectID, n int) (int, int, error) {
+	subscription, err := s.subscription(ctx, companyID)
+	if err != nil {
+		return 0, 0, err
+	}
+	plan := planFor(subscription)
+
+	allowed := n
+	if plan.MaxOpenJobs != models.Unlimited {
+		open, err := s.countOpen(ctx, companyID, primitive.NilObjectID, false)
+		if err != nil {
+			return 0, 0, err
+		}
+		allowed = min(allowed, max(0, plan.MaxOpenJobs-open))
+	}
+	if plan.MonthlyPostings == models.Unlimited {
+		return allowed, 0, nil
+	}
+	start, _ := billingPeriod(time.Now())
+	posted, err := s.countPostings(ctx, companyID, primitive.NilObjectID, start)
+	if err != nil {
+		return 0, 0, err
+	}
+	monthlyLeft := max(0, plan.MonthlyPostings-posted)
+	allowed = min(allowed, monthlyLeft+subscription.Credits)
+
+	credits := max(0, allowed-monthlyLeft)
+	if credits > 0 {
+		filter := bson.M{"company": companyID, "credits": bson.M{"$gte": credits}}
+		update := bson.M{"$inc": bson.M{"credits": -credits}, "$set": bson.M{"updatedAt": time.Now()}}
+		result, err := s.subscriptionCollection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return 0, 0, err
+		}
+		if result.ModifiedCount == 0 {
+			// The credits were spent concurrently; stick to the monthly allowance.
+			allowed, credits = monthlyLeft, 0
+		}
+	}
+	return allowed, credits, nil
+}
+
+func (s *QuotaService) RefundCredits(ctx context.Context, companyID primitive.ObjectID, credits int) error {
+	if credits <= 0 {
+		return nil
+	}
+	update := bson.M{"$inc": bson.M{"credits": credits}, "$set": bson.M{"updatedAt": time.Now()}}
+	_, err := s.subscriptionCollection.UpdateOne(ctx, bson.M{"company": companyID}, update)
+	return err
+}
+
+func (s *QuotaService) AllowFeatured(ctx context.Context, job *models.Job) error {
+	subscription, err := s.subscription(ctx, job.Company)
+	if err != nil {
+		return err
+	}
+	return s.allowFeatured(ctx, job, planFor(subscription))
+}
+
+func (s *QuotaService) allowFeatured(ctx context.Context, job *models.Job, plan models.Plan) error {
+	if plan.FeaturedSlots == models.Unlimited {
+		return nil
+	}
+	featured, err := s.countOpen(ctx, job.Company, job.ID, true)
+	if err != nil {
+		return err
+	}
+	if featured >= plan.FeaturedSlots {
+		return fmt.Errorf("%w: the %s plan allows %d featured jobs", ErrQuotaExceeded, plan.Name, plan.FeaturedSlots)
+	}
+	return nil
+}
+
+// countOpen counts the company's live Open jobs, or only the featured ones, other
+// than the job being checked.
+func (s *QuotaService) countOpen(ctx context.Context, companyID, exclude primitive.ObjectID, featured bool) (int, error) {
+	filter := excludeDeleted(bson.M{"company": companyID, "status": models.Open, "_id": bson.M{"$ne": exclude}})
+	if featured {
+		filter["featured"] = true
+	}
+	count, err := s.jobCollection.CountDocuments(ctx, filter)
+	return int(count), err
+}
+
+// countPostings counts jobs first published since start. Deleted jobs still count,
+// otherwise deleting and reposting would reset the allowance.
+func (s *QuotaService) countPostings(ctx context.Context, companyID, exclude primitive.ObjectID, start time.Time) (int, error) {
+	filter := bson.M{"company": companyID, "publishedAt": bson.M{"$gte": start}, "_id": bson.M{"$ne": exclude}}
+	count, err := s.jobCollection.CountDocuments(ctx, filter)
+	return int(count), err
+}
+
+func planFor(subscription *models.Subscription) models.Plan {
+	if plan, ok := models.Plans[subscription.Plan]; ok {
+		return plan
+	}
+	return models.Plans[models.DefaultPlan]
+}
+
+// billingPeriod is the calendar month (UTC) containing t.
+func billingPeriod(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
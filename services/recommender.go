@@ -0,0 +1,366 @@
+package services
+
+import (
+	"jobsy-api/models"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultRecommendationLimit  = 5
+	MaxRecommendationLimit      = 50
+	MaxRecommendationCandidates = 500
+	nearbyDistanceKm            = 100
+)
+
+// RecommendationWeights controls how much each signal contributes to a score.
+// Signals that cannot be computed for a pair (e.g. no salary on either job) are
+// left out and the remaining weights are renormalised.
+type RecommendationWeights struct {
+	Tags     float64 `json:"tags"`
+	Text     float64 `json:"text"`
+	Location float64 `json:"location"`
+	JobType  float64 `json:"jobType"`
+	WorkType float64 `json:"workType"`
+	Salary   float64 `json:"salary"`
+}
+
+var DefaultRecommendationWeights = RecommendationWeights{
+	Tags:     0.35,
+	Text:     0.25,
+	Location: 0.15,
+	JobType:  0.10,
+	WorkType: 0.05,
+	Salary:   0.10,
+}
+
+type ScoreBreakdown struct {
+	Tags     *float64 `json:"tags,omitempty"`
+	Text     *float64 `json:"text,omitempty"`
+	Location *float64 `json:"location,omitempty"`
+	JobType  *float64 `json:"jobType,omitempty"`
+	WorkType *float64 `json:"workType,omitempty"`
+	Salary   *float64 `json:"salary,omitempty"`
+}
+
+type ScoredJob struct {
+	Job       *models.Job    `json:"job"`
+	Score     float64        `json:"score"`
+	Breakdown ScoreBreakdown `json:"breakdown"`
+}
+
+// jobProfile is what candidates are compared against. It is built from a single
+// job for "similar jobs", but every field is weighted so it can also describe a
+// blend of several jobs.
+type jobProfile struct {
+	tags      map[string]float64
+	terms     map[string]float64
+	points    []*models.GeoPoint
+	locations map[string]float64
+	jobTypes  map[models.JobType]float64
+	workTypes map[models.WorkType]float64
+	salary    float64
+	currency  string
+}
+
+func newJobProfile() *jobProfile {
+	return &jobProfile{
+		tags:      map[string]float64{},
+		terms:     map[string]float64{},
+		locations: map[string]float64{},
+		jobTypes:  map[models.JobType]float64{},
+		workTypes: map[models.WorkType]float64{},
+	}
+}
+
+// add blends a job into the profile with the given weight.
+func (p *jobProfile) add(job *models.Job, weight float64) {
+	for _, tag := range job.Tags {
+		p.tags[tagKey(tag)] += weight
+	}
+	for term, count := range jobTerms(job) {
+		p.terms[term] += count * weight
+	}
+	p.points = append(p.points, jobPoints(job)...)
+	if location := placeKey(job.Location); location != "" {
+		p.locations[location] += weight
+	}
+	if job.JobType != "" {
+		p.jobTypes[job.JobType] += weight
+	}
+	if job.WorkType != "" {
+		p.workTypes[job.WorkType] += weight
+	}
+	if midpoint, ok := salaryMidpoint(job.Salary); ok && p.salary == 0 {
+		p.salary = midpoint
+		p.currency = strings.ToUpper(job.Salary.CurrencyType)
+	}
+}
+
+func profileFromJob(job *models.Job) *jobProfile {
+	profile := newJobProfile()
+	profile.add(job, 1)
+	return profile
+}
+
+// rankJobs scores every candidate against the profile and returns the best ones.
+// Tag and term rarity (IDF) is computed over the candidate pool, so common tags
+// such as "remote" count for less than specific ones.
+func rankJobs(profile *jobProfile, candidates []*models.Job, weights RecommendationWeights, limit int) []*ScoredJob {
+	tagDF := map[string]int{}
+	termDF := map[string]int{}
+	candidateTerms := make([]map[string]float64, len(candidates))
+	for i, job := range candidates {
+		seen := map[string]bool{}
+		for _, tag := range job.Tags {
+			key := tagKey(tag)
+			if !seen[key] {
+				tagDF[key]++
+				seen[key] = true
+			}
+		}
+		candidateTerms[i] = jobTerms(job)
+		for term := range candidateTerms[i] {
+			termDF[term]++
+		}
+	}
+	n := len(candidates)
+	profileVector := tfidf(profile.terms, termDF, n)
+
+	scored := make([]*ScoredJob, 0, len(candidates))
+	for i, job := range candidates {
+		var total, weightSum float64
+		var breakdown ScoreBreakdown
+		apply := func(target **float64, weight, value float64, ok bool) {
+			if !ok || weight == 0 {
+				return
+			}
+			value = math.Round(value*1000) / 1000
+			*target = &value
+			total += weight * value
+			weightSum += weight
+		}
+
+		tagScore, tagOk := weightedTagOverlap(profile.tags, job.Tags, tagDF, n)
+		apply(&breakdown.Tags, weights.Tags, tagScore, tagOk)
+		textScore := cosine(profileVector, tfidf(candidateTerms[i], termDF, n))
+		apply(&breakdown.Text, weights.Text, textScore, len(profileVector) > 0)
+		locationScore, locationOk := locationSimilarity(profile, job)
+		apply(&breakdown.Location, weights.Location, locationScore, locationOk)
+		jobTypeScore, jobTypeOk := categoricalShare(profile.jobTypes, job.JobType)
+		apply(&breakdown.JobType, weights.JobType, jobTypeScore, jobTypeOk)
+		workTypeScore, workTypeOk := workTypeSimilarity(profile.workTypes, job.WorkType)
+		apply(&breakdown.WorkType, weights.WorkType, workTypeScore, workTypeOk)
+		salaryScore, salaryOk := salaryProximity(profile, job.Salary)
+		apply(&breakdown.Salary, weights.Salary, salaryScore, salaryOk)
+
+		if weightSum == 0 {
+			continue
+		}
+		score := math.Round(total/weightSum*1000) / 1000
+		scored = append(scored, &ScoredJob{Job: job, Score: score, Breakdown: breakdown})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		return scored[i].Job.CreatedAt.After(scored[j].Job.CreatedAt)
+	})
+	if len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored
+}
+
+func clampRecommendationLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultRecommendationLimit
+	}
+	return min(limit, MaxRecommendationLimit)
+}
+
+func idf(df, n int) float64 {
+	return math.Log(float64(n+1)/float64(df+1)) + 1
+}
+
+// weightedTagOverlap is a Jaccard index where every tag counts with its IDF and
+// the profile's own weight for it.
+func weightedTagOverlap(profileTags map[string]float64, tags []string, df map[string]int, n int) (float64, bool) {
+	if len(profileTags) == 0 || len(tags) == 0 {
+		return 0, len(profileTags) > 0
+	}
+	var maxWeight float64
+	for _, weight := range profileTags {
+		maxWeight = math.Max(maxWeight, weight)
+	}
+
+	candidate := map[string]bool{}
+	for _, tag := range tags {
+		candidate[tagKey(tag)] = true
+	}
+	var shared, union float64
+	for tag, weight := range profileTags {
+		w := idf(df[tag], n) * weight / maxWeight
+		union += w
+		if candidate[tag] {
+			shared += w
+		}
+	}
+	for tag := range candidate {
+		if _, ok := profileTags[tag]; !ok {
+			union += idf(df[tag], n)
+		}
+	}
+	return shared / union, true
+}
+
+func tfidf(terms map[string]float64, df map[string]int, n int) map[string]float64 {
+	vector := make(map[string]float64, len(terms))
+	for term, tf := range terms {
+		vector[term] = math.Log1p(tf) * idf(df[term], n)
+	}
+	return vector
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, weight := range a {
+		normA += weight * weight
+		dot += weight * b[term]
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// locationSimilarity prefers real distances and falls back to comparing the
+// free-text location when coordinates are missing.
+func locationSimilarity(profile *jobProfile, job *models.Job) (float64, bool) {
+	if points := jobPoints(job); len(profile.points) > 0 && len(points) > 0 {
+		best := 0.0
+		for _, point := range profile.points {
+			for _, location := range points {
+				distance := haversineKm(point, location)
+				best = math.Max(best, math.Max(0, 1-distance/nearbyDistanceKm))
+			}
+		}
+		return best, true
+	}
+	if len(profile.locations) == 0 {
+		return 0, false
+	}
+	score, _ := categoricalShare(profile.locations, placeKey(job.Location))
+	return score, true
+}
+
+// categoricalShare is the share of the profile's weight that matches the value.
+func categoricalShare[K comparable](weights map[K]float64, value K) (float64, bool) {
+	var total float64
+	for _, weight := range weights {
+		total += weight
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return weights[value] / total, true
+}
+
+// workTypeSimilarity treats hybrid as half way between remote and on-site.
+func workTypeSimilarity(weights map[models.WorkType]float64, workType models.WorkType) (float64, bool) {
+	var total, score float64
+	for profileType, weight := range weights {
+		total += weight
+		switch {
+		case profileType == workType:
+			score += weight
+		case profileType == models.HybridWork || workType == models.HybridWork:
+			score += weight / 2
+		}
+	}
+	if total == 0 || workType == "" {
+		return 0, false
+	}
+	return score / total, true
+}
+
+func salaryProximity(profile *jobProfile, salary models.Salary) (float64, bool) {
+	midpoint, ok := salaryMidpoint(salary)
+	if !ok || profile.salary == 0 {
+		return 0, false
+	}
+	if profile.currency != "" && salary.CurrencyType != "" && !strings.EqualFold(profile.currency, salary.CurrencyType) {
+		return 0, true
+	}
+	return 1 - math.Abs(profile.salary-midpoint)/math.Max(profile.salary, midpoint), true
+}
+
+func salaryMidpoint(salary models.Salary) (float64, bool) {
+	min, minOk := parseSalaryAmount(salary.Min)
+	max, maxOk := parseSalaryAmount(salary.Max)
+	switch {
+	case minOk && maxOk:
+		return (min + max) / 2, min+max > 0
+	case minOk:
+		return min, min > 0
+	case maxOk:
+		return max, max > 0
+	default:
+		return 0, false
+	}
+}
+
+func haversineKm(a, b *models.GeoPoint) float64 {
+	const earthRadiusKm = 6371
+	lat1, lat2 := a.Lat()*math.Pi/180, b.Lat()*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng() - a.Lng()) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// tagKey is the case and whitespace insensitive form of a tag, so "Machine  learning"
+// and "machine learning" compare equal.
+func tagKey(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+// jobTerms counts the words of a job, with the title counted twice since it is
+// the strongest description of the role.
+func jobTerms(job *models.Job) map[string]float64 {
+	terms := map[string]float64{}
+	for _, token := range tokenize(job.Title) {
+		terms[token] += 2
+	}
+	for _, token := range tokenize(job.Summary + " " + job.Description) {
+		terms[token]++
+	}
+	return terms
+}
+
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+	tokens := words[:0]
+	for _, word := range words {
+		if len(word) > 1 && !stopWords[word] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "have": true, "in": true, "is": true, "it": true,
+	"of": true, "on": true, "or": true, "our": true, "that": true, "the": true, "this": true,
+	"to": true, "we": true, "will": true, "with": true, "you": true, "your": true, "who": true,
+	"job": true, "role": true, "work": true, "team": true, "experience": true,
+}
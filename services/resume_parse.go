@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"jobsy-api/resume"
+	"log"
+	"strings"
+)
+
+var ErrUnreadableResume = errors.New("no text could be read from the resume")
+
+// SetTagNormalizer makes skills read from resumes use canonical tag names.
+func (s *ApplicantService) SetTagNormalizer(normalizer TagNormalizer) {
+	s.tagNormalizer = normalizer
+}
+
+// ParseResume reads a PDF or DOCX resume and returns what it could find for
+// prefilling an application. Nothing is stored.
+func (s *ApplicantService) ParseResume(upload *ResumeUpload) (profile *resume.Profile, err error) {
+	// The files come from strangers; a parser bug must fail the upload, not the
+	// server.
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			log.Printf("resume parsing panicked on %q: %v", upload.Filename, recovered)
+			profile, err = nil, ErrUnreadableResume
+		}
+	}()
+	_, contentType, err := s.checkResume(upload)
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+	switch contentType {
+	case PDFContentType:
+		text, err = resume.ExtractPDF(upload.Content, upload.Size)
+	case DOCXContentType:
+		text, err = resume.ExtractDOCX(upload.Content, upload.Size)
+	}
+	if err != nil {
+		// Damaged or encrypted files are the candidate's to fix, not ours.
+		return nil, fmt.Errorf("%w: %v", ErrUnreadableResume, err)
+	}
+	// Scanned resumes are images; there is no text without OCR.
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrUnreadableResume
+	}
+
+	profile = resume.Parse(text)
+	if s.tagNormalizer != nil && len(profile.Skills) > 0 {
+		profile.Skills = s.tagNormalizer.NormalizeTags(profile.Skills)
+	}
+	return profile, nil
+}
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"jobsy-api/models"
+	"jobsy-api/storage"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	PDFContentType  = "application/pdf"
+	DOCXContentType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+var (
+	ErrNoResumeFile = errors.New("application has no uploaded resume")
+	ErrNotJobOwner  = errors.New("only the job owner can do this")
+)
+
+// ResumeUpload is a resume file sent with an application.
+type ResumeUpload struct {
+	Filename string
+	Size     int64
+	Content  io.ReaderAt
+}
+
+// ResumeLink is a signed download link for an uploaded resume.
+type ResumeLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SetResumeStorage enables resume uploads of up to maxBytes, downloadable through
+// links valid for linkTTL.
+func (s *ApplicantService) SetResumeStorage(store storage.Storage, maxBytes int64, linkTTL time.Duration) {
+	s.storage = store
+	s.maxResumeBytes = maxBytes
+	s.resumeLinkTTL = linkTTL
+}
+
+// MaxResumeBytes is the size limit of resume uploads.
+func (s *ApplicantService) MaxResumeBytes() int64 {
+	return s.maxResumeBytes
+}
+
+// resumeFile checks an upload and names the file it will be stored as. Each upload
+// gets its own key, so a replaced resume never overwrites the one still in use.
+func (s *ApplicantService) resumeFile(applicantID primitive.ObjectID, upload *ResumeUpload) (*models.ResumeFile, error) {
+	if s.storage == nil {
+		return nil, fmt.Errorf("%w: resume uploads are not enabled", ErrInvalidApplication)
+	}
+	name, contentType, err := s.checkResume(upload)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ResumeFile{
+		Key:         "resumes/" + applicantID.Hex() + "/" + primitive.NewObjectID().Hex() + strings.ToLower(path.Ext(name)),
+		Name:        name,
+		ContentType: contentType,
+		Size:        upload.Size,
+	}, nil
+}
+
+// checkResume checks an upload is a PDF or DOCX file within the size limit and
+// returns its base name and content type. The content must match the extension;
+// the client's declared type is not trusted.
+func (s *ApplicantService) checkResume(upload *ResumeUpload) (string, string, error) {
+	if upload.Size <= 0 {
+		return "", "", fmt.Errorf("%w: the resume file is empty", ErrInvalidApplication)
+	}
+	if upload.Size > s.maxResumeBytes {
+		return "", "", fmt.Errorf("%w: the resume must be at most %d MB", ErrInvalidApplication, s.maxResumeBytes>>20)
+	}
+
+	name := path.Base(strings.ReplaceAll(upload.Filename, "\\", "/"))
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	switch extension := strings.ToLower(path.Ext(name)); {
+	case extension == ".pdf" && isPDF(upload):
+		return name, PDFContentType, nil
+	case extension == ".docx" && isDOCX(upload):
+		return name, DOCXContentType, nil
+	default:
+		return "", "", fmt.Errorf("%w: the resume must be a PDF or DOCX file", ErrInvalidApplication)
+	}
+}
+
+func isPDF(upload *ResumeUpload) bool {
+	header := make([]byte, 5)
+	_, err := upload.Content.ReadAt(header, 0)
+	return err == nil && bytes.Equal(header, []byte("%PDF-"))
+}
+
+// isDOCX looks for the parts every Word document has inside the zip container.
+func isDOCX(upload *ResumeUpload) bool {
+	archive, err := zip.NewReader(upload.Content, upload.Size)
+	if err != nil {
+		return false
+	}
+	var contentTypes, document bool
+	for _, file := range archive.File {
+		switch file.Name {
+		case "[Content_Types].xml":
+			contentTypes = true
+		case "word/document.xml":
+			document = true
+		}
+	}
+	return contentTypes && document
+}
+
+func (s *ApplicantService) storeResume(ctx context.Context, file *models.ResumeFile, upload *ResumeUpload) error {
+	return s.storage.Put(ctx, file.Key, io.NewSectionReader(upload.Content, 0, upload.Size), upload.Size, file.ContentType)
+}
+
+// deleteResume removes a stored resume. The application is already gone or was
+// never saved, so a failure only leaves an orphaned file behind.
+func (s *ApplicantService) deleteResume(ctx context.Context, file *models.ResumeFile) {
+	if file == nil || s.storage == nil {
+		return
+	}
+	if err := s.storage.Delete(ctx, file.Key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("failed to delete resume %s: %v", file.Key, err)
+	}
+}
+
+// GetResumeLink returns a short-lived download link for an application's resume.
+// Only the company that owns the job gets one.
+func (s *ApplicantService) GetResumeLink(id, userID primitive.ObjectID) (*ResumeLink, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var applicant models.Applicant
+	err := s.applicantCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&applicant)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrApplicantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job models.Job
+	err = s.jobCollection.FindOne(ctx, bson.M{"_id": applicant.JobID}, options.FindOne().SetProjection(bson.M{"company": 1})).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if job.Company != userID {
+		return nil, ErrNotJobOwner
+	}
+	if applicant.ResumeFile == nil || s.storage == nil {
+		return nil, ErrNoResumeFile
+	}
+
+	expiresAt := time.Now().Add(s.resumeLinkTTL)
+	url, err := s.storage.SignedURL(applicant.ResumeFile.Key, s.resumeLinkTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &ResumeLink{URL: url, ExpiresAt: expiresAt}, nil
+}
+
+// DeleteResumesForJobs removes the resumes uploaded to jobs that are being purged.
+func (s *ApplicantService) DeleteResumesForJobs(ctx context.Context, jobIDs []primitive.ObjectID) error {
+	if s.storage == nil {
+		return nil
+	}
+	cursor, err := s.applicantCollection.Find(ctx,
+		bson.M{"jobId": bson.M{"$in": jobIDs}, "resumeFile": bson.M{"$exists": true}},
+		options.Find().SetProjection(bson.M{"resumeFile": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var applicants []models.Applicant
+	if err = cursor.All(ctx, &applicants); err != nil {
+		return err
+	}
+	for _, applicant := range applicants {
+		err := s.storage.Delete(ctx, applicant.ResumeFile.Key)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
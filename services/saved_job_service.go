@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/config"
+	"jobsy-api/models"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrJobNotFound      = errors.New("job not found")
+	ErrSavedJobNotFound = errors.New("saved job not found")
+)
+
+type SavedJobState string
+
+const (
+	SavedJobAvailable SavedJobState = "available"
+	SavedJobClosed    SavedJobState = "closed"
+	SavedJobDeleted   SavedJobState = "deleted"
+)
+
+// SavedJobListing is a bookmark with the job as it is now. Job is nil and State is
+// "deleted" when the job has been deleted; State is "closed" when it is no longer
+// Open, and Job is nil then too if the job went back to draft or review.
+type SavedJobListing struct {
+	ID      primitive.ObjectID `json:"id"`
+	JobID   primitive.ObjectID `json:"jobId"`
+	SavedAt time.Time          `json:"savedAt"`
+	State   SavedJobState      `json:"state"`
+	Job     *models.Job        `json:"job,omitempty"`
+}
+
+type SavedJobService struct {
+	savedJobCollection *mongo.Collection
+	jobCollection      *mongo.Collection
+	baseURL            string
+}
+
+func NewSavedJobService(db *mongo.Client, savedJobCollectionName, jobCollectionName string) *SavedJobService {
+	return &SavedJobService{
+		savedJobCollection: db.Database("jobsy-api").Collection(savedJobCollectionName),
+		jobCollection:      db.Database("jobsy-api").Collection(jobCollectionName),
+		baseURL:            config.PublicBaseURL(),
+	}
+}
+
+func (s *SavedJobService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.savedJobCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "jobId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "jobId", Value: 1}}},
+	})
+	return err
+}
+
+// DeleteForJobs removes every bookmark of the given jobs. It is registered as a
+// JobService purge hook.
+func (s *SavedJobService) DeleteForJobs(ctx context.Context, jobIDs []primitive.ObjectID) error {
+	_, err := s.savedJobCollection.DeleteMany(ctx, bson.M{"jobId": bson.M{"$in": jobIDs}})
+	return err
+}
+
+// SaveJob bookmarks a job for the user. Only Open jobs can be saved, and saving
+// the same job twice is a no-op.
+func (s *SavedJobService) SaveJob(userID, jobID primitive.ObjectID) (*models.SavedJob, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	count, err := s.jobCollection.CountDocuments(ctx, excludeDeleted(bson.M{"_id": jobID, "status": models.Open}))
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrJobNotFound
+	}
+
+	filter := bson.M{"userId": userID, "jobId": jobID}
+	update := bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "createdAt": time.Now()}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved models.SavedJob
+	err = s.savedJobCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&saved)
+	if mongo.IsDuplicateKeyError(err) {
+		err = s.savedJobCollection.FindOne(ctx, filter).Decode(&saved)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (s *SavedJobService) UnsaveJob(userID, jobID primitive.ObjectID) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	result, err := s.savedJobCollection.DeleteOne(ctx, bson.M{"userId": userID, "jobId": jobID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrSavedJobNotFound
+	}
+	return nil
+}
+
+// GetSavedJobs lists the user's bookmarks, newest first, flagging jobs that have
+// been closed or deleted since they were saved. Jobs that were hidden since are
+// listed as closed, without their content.
+func (s *SavedJobService) GetSavedJobs(userID primitive.ObjectID) ([]*SavedJobListing, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	opts := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := s.savedJobCollection.Find(ctx, bson.M{"userId": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved []*models.SavedJob
+	if err = cursor.All(ctx, &saved); err != nil {
+		return nil, err
+	}
+
+	jobIDs := make([]primitive.ObjectID, 0, len(saved))
+	for _, bookmark := range saved {
+		jobIDs = append(jobIDs, bookmark.JobID)
+	}
+	cursor, err = s.jobCollection.Find(ctx, excludeDeleted(bson.M{"_id": bson.M{"$in": jobIDs}}))
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*models.Job
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*models.Job, len(jobs))
+	for _, job := range jobs {
+		job.CanonicalURL = jobURL(s.baseURL, job)
+		byID[job.ID] = job
+	}
+
+	listings := make([]*SavedJobListing, 0, len(saved))
+	for _, bookmark := range saved {
+		listing := &SavedJobListing{ID: bookmark.ID, JobID: bookmark.JobID, SavedAt: bookmark.CreatedAt}
+		job, ok := byID[bookmark.JobID]
+		switch {
+		case !ok:
+			listing.State = SavedJobDeleted
+		case slices.Contains(hiddenJobStatuses, job.Status):
+			listing.State = SavedJobClosed
+		case job.Status != models.Open:
+			listing.State = SavedJobClosed
+			listing.Job = job
+		default:
+			listing.State = SavedJobAvailable
+			listing.Job = job
+		}
+		listings = append(listings, listing)
+	}
+	return listings, nil
+}
+
+// CountSaves returns how many users bookmarked the job.
+func (s *SavedJobService) CountSaves(jobID primitive.ObjectID) (int64, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	return s.savedJobCollection.CountDocuments(ctx, bson.M{"jobId": jobID})
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunEvery runs task every interval until ctx is cancelled. Failures are logged and
+// the task is tried again on the next tick.
+func RunEvery(ctx context.Context, interval time.Duration, name string, task func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := task(); err != nil {
+				log.Printf("%s failed: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"jobsy-api/models"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxSlugLength = 80
+
+// slugify builds a lowercase, hyphen separated ASCII slug from the given parts,
+// e.g. "Senior Go Developer", "Acme", "Pune" -> "senior-go-developer-acme-pune".
+func slugify(parts ...string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(strings.ToLower(strings.Join(parts, " "))) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		default:
+			hyphen = true
+		}
+	}
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		if cut := strings.LastIndexByte(slug, '-'); cut > maxSlugLength/2 {
+			slug = slug[:cut]
+		}
+	}
+	return strings.Trim(slug, "-")
+}
+
+// jobSlug picks a slug for the job that no other job uses, currently or as an old
+// slug. Slugs in reserved are treated as taken too, which keeps a batch of new jobs
+// from colliding with each other.
+func (s *JobService) jobSlug(ctx context.Context, job *models.Job, companyName string, reserved map[string]bool) (string, error) {
+	base := slugify(job.Title, companyName, job.Location)
+	if base == "" {
+		base = "job"
+	}
+	for _, candidate := range []string{base, base + "-" + job.ID.Hex()[18:]} {
+		if reserved[candidate] {
+			continue
+		}
+		filter := bson.M{
+			"_id": bson.M{"$ne": job.ID},
+			"$or": bson.A{bson.M{"slug": candidate}, bson.M{"previousSlugs": candidate}},
+		}
+		count, err := s.jobCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			if reserved != nil {
+				reserved[candidate] = true
+			}
+			return candidate, nil
+		}
+	}
+	// The full ObjectID is unique by construction.
+	return base + "-" + job.ID.Hex(), nil
+}
+
+func (s *JobService) companyName(ctx context.Context, userID primitive.ObjectID) (string, error) {
+	var company models.Company
+	opts := options.FindOne().SetProjection(bson.M{"name": 1})
+	err := s.companyCollection.FindOne(ctx, bson.M{"userId": userID}, opts).Decode(&company)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	return company.Name, err
+}
+
+// GetJobByRef resolves a job by ObjectID or slug. moved is true when ref is an old
+// slug of the job, so callers can redirect to the canonical URL.
+func (s *JobService) GetJobByRef(ref string) (job *models.Job, moved bool, err error) {
+	if id, err := primitive.ObjectIDFromHex(ref); err == nil {
+		job, err := s.GetJobByID(id)
+		return job, false, err
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	job = &models.Job{}
+	err = s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"slug": ref})).Decode(job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"previousSlugs": ref})).Decode(job)
+		moved = err == nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	s.canonical(job)
+	return job, moved, nil
+}
+
+// GetVisibleJobByRef is GetJobByRef for public pages. Drafts and jobs held or
+// rejected in review are only found by their owner and admins, who also get the
+// moderation details; viewerID is zero for anonymous visitors.
+func (s *JobService) GetVisibleJobByRef(ref string, viewerID primitive.ObjectID, role string) (*models.Job, bool, error) {
+	job, moved, err := s.GetJobByRef(ref)
+	if err != nil {
+		return nil, false, err
+	}
+	if canSeeJob(job, viewerID, role) {
+		revealModeration(job)
+	} else if slices.Contains(hiddenJobStatuses, job.Status) {
+		return nil, false, ErrJobNotFound
+	}
+	return job, moved, nil
+}
+
+// canonical fills in the canonical URL of each job.
+func (s *JobService) canonical(jobs ...*models.Job) {
+	for _, job := range jobs {
+		job.CanonicalURL = jobURL(s.baseURL, job)
+	}
+}
+
+// BackfillSlugs gives every job created before slugs existed one.
+func (s *JobService) BackfillSlugs() (int, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	cursor, err := s.jobCollection.Find(ctx, bson.M{"slug": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, err
+	}
+	var jobs []*models.Job
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return 0, err
+	}
+
+	names := map[primitive.ObjectID]string{}
+	updated := 0
+	for _, job := range jobs {
+		name, ok := names[job.Company]
+		if !ok {
+			if name, err = s.companyName(ctx, job.Company); err != nil {
+				return updated, err
+			}
+			names[job.Company] = name
+		}
+		slug, err := s.jobSlug(ctx, job, name, nil)
+		if err != nil {
+			return updated, err
+		}
+		if _, err := s.jobCollection.UpdateOne(ctx, bson.M{"_id": job.ID}, bson.M{"$set": bson.M{"slug": slug}}); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
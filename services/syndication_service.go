@@ -0,0 +1,586 @@
+package services
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"jobsy-api/models"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultFeedLimit = 100
+	MaxFeedLimit     = 500
+)
+
+// FeedEntry pairs a job with the profile of the company that posted it. Company is
+// nil when the posting user has not onboarded a company profile yet.
+type FeedEntry struct {
+	Job     *models.Job
+	Company *models.Company
+}
+
+type FeedInfo struct {
+	Title       string
+	Description string
+	BaseURL     string
+	Updated     time.Time
+}
+
+type SyndicationService struct {
+	jobCollection  *mongo.Collection
+	companyService *CompanyService
+}
+
+func NewSyndicationService(db *mongo.Client, jobCollectionName string, companyService *CompanyService) *SyndicationService {
+	return &SyndicationService{
+		jobCollection:  db.Database("jobsy-api").Collection(jobCollectionName),
+		companyService: companyService,
+	}
+}
+
+// GetFeedEntries returns the newest Open jobs, optionally restricted to one company.
+func (s *SyndicationService) GetFeedEntries(companyID *primitive.ObjectID, limit int64) ([]FeedEntry, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if limit <= 0 {
+		limit = DefaultFeedLimit
+	}
+	limit = min(limit, MaxFeedLimit)
+
+	filter := excludeDeleted(bson.M{"status": models.Open})
+	if companyID != nil {
+		filter["company"] = *companyID
+	}
+	opts := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(limit)
+	cursor, err := s.jobCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []*models.Job
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return s.withCompanies(jobs)
+}
+
+// GetFeedEntry returns a single Open job with its company.
+func (s *SyndicationService) GetFeedEntry(jobID primitive.ObjectID) (*FeedEntry, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	var job models.Job
+	err := s.jobCollection.FindOne(ctx, excludeDeleted(bson.M{"_id": jobID, "status": models.Open})).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.withCompanies([]*models.Job{&job})
+	if err != nil {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+func (s *SyndicationService) withCompanies(jobs []*models.Job) ([]FeedEntry, error) {
+	userIDs := make([]primitive.ObjectID, 0, len(jobs))
+	for _, job := range jobs {
+		userIDs = append(userIDs, job.Company)
+	}
+	companies, err := s.companyService.GetCompaniesByUserIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]FeedEntry, 0, len(jobs))
+	for _, job := range jobs {
+		entries = append(entries, FeedEntry{Job: job, Company: companies[job.Company]})
+	}
+	return entries, nil
+}
+
+// jobURL is the canonical address of a job: its slug when it has one, otherwise
+// its ID.
+func jobURL(baseURL string, job *models.Job) string {
+	if job.Slug != "" {
+		return fmt.Sprintf("%s/api/jobs/%s", baseURL, job.Slug)
+	}
+	return jobPermalink(baseURL, job)
+}
+
+// jobPermalink addresses a job by ID. Feed entry IDs use it because, unlike the
+// slug, it never changes.
+func jobPermalink(baseURL string, job *models.Job) string {
+	return fmt.Sprintf("%s/api/jobs/%s", baseURL, job.ID.Hex())
+}
+
+func companyName(company *models.Company) string {
+	if company == nil {
+		return ""
+	}
+	return company.Name
+}
+
+func feedUpdated(info FeedInfo, entries []FeedEntry) time.Time {
+	updated := info.Updated
+	for _, entry := range entries {
+		if entry.Job.UpdatedAt.After(updated) {
+			updated = entry.Job.UpdatedAt
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	return updated.UTC()
+}
+
+// RSS 2.0
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func RenderRSS(info FeedInfo, entries []FeedEntry) ([]byte, error) {
+	channel := rssChannel{
+		Title:         info.Title,
+		Link:          info.BaseURL,
+		Description:   info.Description,
+		LastBuildDate: feedUpdated(info, entries).Format(time.RFC1123Z),
+	}
+	for _, entry := range entries {
+		job := entry.Job
+		link := jobURL(info.BaseURL, job)
+		categories := []string{string(job.JobType), string(job.WorkType)}
+		categories = append(categories, job.Tags...)
+		channel.Items = append(channel.Items, rssItem{
+			Title:       feedTitle(entry),
+			Link:        link,
+			GUID:        rssGUID{IsPermaLink: true, Value: jobPermalink(info.BaseURL, job)},
+			PubDate:     job.CreatedAt.UTC().Format(time.RFC1123Z),
+			Categories:  nonEmpty(categories),
+			Description: feedSummary(job),
+		})
+	}
+	return marshalXML(rssFeed{Version: "2.0", Channel: channel})
+}
+
+// Atom 1.0
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Link       atomLink       `xml:"link"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Summary    string         `xml:"summary"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+func RenderAtom(info FeedInfo, entries []FeedEntry) ([]byte, error) {
+	feed := atomFeed{
+		ID:      info.BaseURL + "/api/feeds/jobs.atom",
+		Title:   info.Title,
+		Updated: feedUpdated(info, entries).Format(time.RFC3339),
+		Link: []atomLink{
+			{Rel: "self", Href: info.BaseURL + "/api/feeds/jobs.atom"},
+			{Rel: "alternate", Href: info.BaseURL},
+		},
+	}
+	for _, entry := range entries {
+		job := entry.Job
+		atom := atomEntry{
+			ID:        jobPermalink(info.BaseURL, job),
+			Title:     feedTitle(entry),
+			Updated:   job.UpdatedAt.UTC().Format(time.RFC3339),
+			Published: job.CreatedAt.UTC().Format(time.RFC3339),
+			Link:      atomLink{Rel: "alternate", Href: jobURL(info.BaseURL, job)},
+			Summary:   feedSummary(job),
+		}
+		if entry.Company != nil {
+			atom.Author = &atomAuthor{Name: entry.Company.Name, URI: entry.Company.Website}
+		}
+		for _, term := range nonEmpty(append([]string{string(job.JobType), string(job.WorkType)}, job.Tags...)) {
+			atom.Categories = append(atom.Categories, atomCategory{Term: term})
+		}
+		feed.Entries = append(feed.Entries, atom)
+	}
+	return marshalXML(feed)
+}
+
+// XML job feed in the <source><job/></source> layout used by most job aggregators.
+
+type xmlJobSource struct {
+	XMLName       xml.Name `xml:"source"`
+	Publisher     string   `xml:"publisher"`
+	PublisherURL  string   `xml:"publisherurl"`
+	LastBuildDate string   `xml:"lastBuildDate"`
+	Jobs          []xmlJob `xml:"job"`
+}
+
+type xmlJob struct {
+	Title           cdata  `xml:"title"`
+	Date            string `xml:"date"`
+	ReferenceNumber string `xml:"referencenumber"`
+	URL             string `xml:"url"`
+	Company         cdata  `xml:"company"`
+	City            cdata  `xml:"city"`
+	State           cdata  `xml:"state,omitempty"`
+	Country         cdata  `xml:"country,omitempty"`
+	PostalCode      string `xml:"postalcode,omitempty"`
+	Description     cdata  `xml:"description"`
+	Salary          string `xml:"salary,omitempty"`
+	JobType         string `xml:"jobtype"`
+	RemoteType      string `xml:"remotetype,omitempty"`
+	Category        cdata  `xml:"category,omitempty"`
+}
+
+type cdata struct {
+	Value string `xml:",cdata"`
+}
+
+func RenderXMLFeed(info FeedInfo, entries []FeedEntry) ([]byte, error) {
+	source := xmlJobSource{
+		Publisher:     info.Title,
+		PublisherURL:  info.BaseURL,
+		LastBuildDate: feedUpdated(info, entries).Format(time.RFC1123Z),
+	}
+	for _, entry := range entries {
+		job := entry.Job
+		item := xmlJob{
+			Title:           cdata{job.Title},
+			Date:            job.CreatedAt.UTC().Format(time.RFC1123Z),
+			ReferenceNumber: job.ID.Hex(),
+			URL:             jobURL(info.BaseURL, job),
+			Company:         cdata{companyName(entry.Company)},
+			City:            cdata{job.Location},
+			Description:     cdata{descriptionHTML(job)},
+			Salary:          salaryText(job.Salary),
+			JobType:         xmlJobType(job.JobType),
+			RemoteType:      xmlRemoteType(job.WorkType),
+			Category:        cdata{strings.Join(job.Tags, ", ")},
+		}
+		if entry.Company != nil {
+			item.State = cdata{entry.Company.State}
+			item.Country = cdata{entry.Company.Country}
+			if entry.Company.Pincode != 0 {
+				item.PostalCode = fmt.Sprint(entry.Company.Pincode)
+			}
+		}
+		source.Jobs = append(source.Jobs, item)
+	}
+	return marshalXML(source)
+}
+
+func xmlJobType(jobType models.JobType) string {
+	switch jobType {
+	case models.FullTime, models.Fresher:
+		return "fulltime"
+	case models.Contract:
+		return "contract"
+	case models.Temporary:
+		return "temporary"
+	case models.Internship:
+		return "internship"
+	default:
+		return ""
+	}
+}
+
+func xmlRemoteType(workType models.WorkType) string {
+	switch workType {
+	case models.Remote:
+		return "Fully remote"
+	case models.HybridWork:
+		return "Hybrid remote"
+	default:
+		return ""
+	}
+}
+
+// schema.org JobPosting
+
+type JobPosting struct {
+	Context                       string               `json:"@context"`
+	Type                          string               `json:"@type"`
+	Title                         string               `json:"title"`
+	Description                   string               `json:"description"`
+	Identifier                    *PropertyValue       `json:"identifier,omitempty"`
+	DatePosted                    string               `json:"datePosted"`
+	URL                           string               `json:"url"`
+	EmploymentType                []string             `json:"employmentType,omitempty"`
+	ExperienceRequirements        string               `json:"experienceRequirements,omitempty"`
+	HiringOrganization            *Organization        `json:"hiringOrganization,omitempty"`
+	JobLocation                   []Place              `json:"jobLocation,omitempty"`
+	JobLocationType               string               `json:"jobLocationType,omitempty"`
+	ApplicantLocationRequirements []AdministrativeArea `json:"applicantLocationRequirements,omitempty"`
+	BaseSalary                    *MonetaryAmount      `json:"baseSalary,omitempty"`
+	Skills                        string               `json:"skills,omitempty"`
+}
+
+type PropertyValue struct {
+	Type  string `json:"@type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Organization struct {
+	Type   string `json:"@type"`
+	Name   string `json:"name"`
+	SameAs string `json:"sameAs,omitempty"`
+	Logo   string `json:"logo,omitempty"`
+}
+
+type Place struct {
+	Type    string        `json:"@type"`
+	Address PostalAddress `json:"address"`
+}
+
+type PostalAddress struct {
+	Type            string `json:"@type"`
+	StreetAddress   string `json:"streetAddress,omitempty"`
+	AddressLocality string `json:"addressLocality,omitempty"`
+	AddressRegion   string `json:"addressRegion,omitempty"`
+	PostalCode      string `json:"postalCode,omitempty"`
+	AddressCountry  string `json:"addressCountry,omitempty"`
+}
+
+type AdministrativeArea struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+type MonetaryAmount struct {
+	Type     string            `json:"@type"`
+	Currency string            `json:"currency,omitempty"`
+	Value    QuantitativeValue `json:"value"`
+}
+
+type QuantitativeValue struct {
+	Type     string   `json:"@type"`
+	Value    *float64 `json:"value,omitempty"`
+	MinValue *float64 `json:"minValue,omitempty"`
+	MaxValue *float64 `json:"maxValue,omitempty"`
+	UnitText string   `json:"unitText"`
+}
+
+// BuildJobPosting maps a job and its company onto schema.org JobPosting for JSON-LD.
+func BuildJobPosting(baseURL string, entry FeedEntry) *JobPosting {
+	job, company := entry.Job, entry.Company
+	posting := &JobPosting{
+		Context:        "https://schema.org/",
+		Type:           "JobPosting",
+		Title:          job.Title,
+		Description:    descriptionHTML(job),
+		DatePosted:     job.CreatedAt.UTC().Format("2006-01-02"),
+		URL:            jobURL(baseURL, job),
+		EmploymentType: schemaEmploymentType(job.JobType),
+		Skills:         strings.Join(job.Tags, ", "),
+		BaseSalary:     schemaSalary(job.Salary),
+	}
+	if job.JobType == models.Fresher {
+		posting.ExperienceRequirements = "No prior experience required"
+	}
+
+	if company != nil {
+		posting.HiringOrganization = &Organization{
+			Type:   "Organization",
+			Name:   company.Name,
+			SameAs: company.Website,
+			Logo:   company.Avatar,
+		}
+		posting.Identifier = &PropertyValue{Type: "PropertyValue", Name: company.Name, Value: job.ID.Hex()}
+	}
+
+	countries := map[string]bool{}
+	for _, location := range jobLocations(job) {
+		address := schemaAddress(location, company)
+		switch locationWorkType(job, location) {
+		case models.Remote:
+			posting.JobLocationType = "TELECOMMUTE"
+			if address.AddressCountry != "" && !countries[address.AddressCountry] {
+				countries[address.AddressCountry] = true
+				posting.ApplicantLocationRequirements = append(posting.ApplicantLocationRequirements, AdministrativeArea{Type: "Country", Name: address.AddressCountry})
+			}
+		default:
+			// Hybrid jobs are listed at their office only; TELECOMMUTE is for jobs
+			// that can be done entirely from home.
+			posting.JobLocation = append(posting.JobLocation, Place{Type: "Place", Address: address})
+		}
+	}
+	return posting
+}
+
+// schemaAddress builds the postal address of a job location. Locations without a
+// region or country, such as jobs saved with a single location string, take them
+// from the company, and the street address is added when the city is the company's.
+func schemaAddress(location models.JobLocation, company *models.Company) PostalAddress {
+	address := PostalAddress{
+		Type:            "PostalAddress",
+		AddressLocality: location.City,
+		AddressRegion:   location.Region,
+		AddressCountry:  location.Country,
+	}
+	if company == nil {
+		return address
+	}
+	if address.AddressRegion == "" && address.AddressCountry == "" {
+		address.AddressRegion = company.State
+		address.AddressCountry = company.Country
+	}
+	if location.City != "" && strings.EqualFold(location.City, company.City) {
+		address.StreetAddress = company.Address
+		if company.Pincode != 0 {
+			address.PostalCode = fmt.Sprint(company.Pincode)
+		}
+	}
+	return address
+}
+
+func schemaEmploymentType(jobType models.JobType) []string {
+	switch jobType {
+	case models.FullTime, models.Fresher:
+		return []string{"FULL_TIME"}
+	case models.Contract:
+		return []string{"CONTRACTOR"}
+	case models.Temporary:
+		return []string{"TEMPORARY"}
+	case models.Internship:
+		return []string{"INTERN"}
+	default:
+		return nil
+	}
+}
+
+// schemaSalary only emits a salary when at least one bound is numeric; negotiable
+// salaries without figures are left out rather than published as zero.
+func schemaSalary(salary models.Salary) *MonetaryAmount {
+	min, minOk := parseSalaryAmount(salary.Min)
+	max, maxOk := parseSalaryAmount(salary.Max)
+	if !minOk && !maxOk {
+		return nil
+	}
+
+	value := QuantitativeValue{Type: "QuantitativeValue", UnitText: "YEAR"}
+	switch {
+	case minOk && maxOk && min == max:
+		value.Value = &min
+	default:
+		if minOk {
+			value.MinValue = &min
+		}
+		if maxOk {
+			value.MaxValue = &max
+		}
+	}
+	return &MonetaryAmount{Type: "MonetaryAmount", Currency: strings.ToUpper(salary.CurrencyType), Value: value}
+}
+
+func salaryText(salary models.Salary) string {
+	var text string
+	switch {
+	case salary.Min != "" && salary.Max != "":
+		text = salary.Min + " - " + salary.Max
+	case salary.Min != "":
+		text = "From " + salary.Min
+	case salary.Max != "":
+		text = "Up to " + salary.Max
+	case salary.Negotiable:
+		return "Negotiable"
+	default:
+		return ""
+	}
+	if salary.CurrencyType != "" {
+		text = strings.ToUpper(salary.CurrencyType) + " " + text
+	}
+	return text
+}
+
+func feedTitle(entry FeedEntry) string {
+	title := entry.Job.Title
+	if name := companyName(entry.Company); name != "" {
+		title += " at " + name
+	}
+	if entry.Job.Location != "" {
+		title += " (" + entry.Job.Location + ")"
+	}
+	return title
+}
+
+func feedSummary(job *models.Job) string {
+	if job.Summary != "" {
+		return job.Summary
+	}
+	return job.Description
+}
+
+func nonEmpty(values []string) []string {
+	result := []string{}
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
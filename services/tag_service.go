@@ -0,0 +1,448 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"jobsy-api/models"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultTagSuggestions = 10
+	MaxTagSuggestions     = 50
+)
+
+var (
+	ErrInvalidTag  = errors.New("invalid tag")
+	ErrTagNotFound = errors.New("tag not found")
+)
+
+// TagNormalizer rewrites free-form tags to their canonical names.
+type TagNormalizer interface {
+	NormalizeTags(tags []string) []string
+}
+
+// TagSuggestion is an autocomplete entry. Count is the number of live jobs using
+// the tag, as of the last usage count.
+type TagSuggestion struct {
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+	Count    int    `json:"count"`
+}
+
+type TagMergeRequest struct {
+	Sources  []string `json:"sources" binding:"required"`
+	Target   string   `json:"target" binding:"required"`
+	Category string   `json:"category"`
+}
+
+type TagMergeResult struct {
+	Tag         *models.Tag `json:"tag"`
+	JobsUpdated int         `json:"jobsUpdated"`
+}
+
+// TagService manages the tag vocabulary. Lookups used on every job write are served
+// from an in-memory index that is rebuilt whenever the vocabulary changes here and
+// periodically through Load, so other instances pick up changes too.
+type TagService struct {
+	tagCollection *mongo.Collection
+	jobCollection *mongo.Collection
+
+	mu    sync.RWMutex
+	byKey map[string]*models.Tag
+}
+
+func NewTagService(db *mongo.Client, tagCollectionName, jobCollectionName string) *TagService {
+	return &TagService{
+		tagCollection: db.Database("jobsy-api").Collection(tagCollectionName),
+		jobCollection: db.Database("jobsy-api").Collection(jobCollectionName),
+		byKey:         map[string]*models.Tag{},
+	}
+}
+
+func (s *TagService) EnsureIndexes() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	_, err := s.tagCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "synonyms", Value: 1}}},
+	})
+	return err
+}
+
+// Load rebuilds the in-memory index from the database.
+func (s *TagService) Load() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	cursor, err := s.tagCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var tags []*models.Tag
+	if err = cursor.All(ctx, &tags); err != nil {
+		return err
+	}
+
+	byKey := make(map[string]*models.Tag, len(tags))
+	for _, tag := range tags {
+		byKey[tag.Key] = tag
+		for _, synonym := range tag.Synonyms {
+			byKey[synonym] = tag
+		}
+	}
+	s.mu.Lock()
+	s.byKey = byKey
+	s.mu.Unlock()
+	return nil
+}
+
+// NormalizeTags maps every tag to its canonical name, keeps unknown tags as typed
+// (trimmed) and drops duplicates, preserving order.
+func (s *TagService) NormalizeTags(tags []string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, tag := range tags {
+		key := tagKey(tag)
+		if key == "" {
+			continue
+		}
+		name := strings.Join(strings.Fields(tag), " ")
+		if canonical, ok := s.byKey[key]; ok {
+			key, name = canonical.Key, canonical.Name
+		}
+		if !seen[key] {
+			seen[key] = true
+			normalized = append(normalized, name)
+		}
+	}
+	return normalized
+}
+
+func (s *TagService) GetTags(category string) ([]*models.Tag, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	filter := bson.M{}
+	if category != "" {
+		filter["category"] = category
+	}
+	cursor, err := s.tagCollection.Find(ctx, filter, options.Find().SetSort(bson.M{"key": 1}))
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []*models.Tag{}
+	if err = cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (s *TagService) CreateTag(tag *models.Tag) (*models.Tag, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if err := s.prepareTag(tag, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+	tag.ID = primitive.NewObjectID()
+	tag.Usage = 0
+	tag.CreatedAt = time.Now()
+	tag.UpdatedAt = time.Now()
+	if _, err := s.tagCollection.InsertOne(ctx, tag); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("%w: %q already exists", ErrInvalidTag, tag.Name)
+		}
+		return nil, err
+	}
+	return tag, s.Load()
+}
+
+// UpdateTag replaces the name, synonyms and category of a tag. Jobs are not
+// rewritten; use MergeTags to fold existing tags into another.
+func (s *TagService) UpdateTag(id primitive.ObjectID, tag *models.Tag) (*models.Tag, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if err := s.prepareTag(tag, id); err != nil {
+		return nil, err
+	}
+	update := bson.M{"$set": bson.M{
+		"name":      tag.Name,
+		"key":       tag.Key,
+		"synonyms":  tag.Synonyms,
+		"category":  tag.Category,
+		"updatedAt": time.Now(),
+	}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var updated models.Tag
+	err := s.tagCollection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrTagNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("%w: %q already exists", ErrInvalidTag, tag.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, s.Load()
+}
+
+func (s *TagService) DeleteTag(id primitive.ObjectID) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	result, err := s.tagCollection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrTagNotFound
+	}
+	return s.Load()
+}
+
+// prepareTag normalises the name and synonyms and makes sure none of them is
+// already claimed by another tag.
+func (s *TagService) prepareTag(tag *models.Tag, id primitive.ObjectID) error {
+	tag.Name = strings.Join(strings.Fields(tag.Name), " ")
+	tag.Key = tagKey(tag.Name)
+	tag.Category = strings.TrimSpace(tag.Category)
+	if tag.Key == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTag)
+	}
+
+	synonyms := []string{}
+	seen := map[string]bool{tag.Key: true}
+	for _, synonym := range tag.Synonyms {
+		if key := tagKey(synonym); key != "" && !seen[key] {
+			seen[key] = true
+			synonyms = append(synonyms, key)
+		}
+	}
+	tag.Synonyms = synonyms
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key := range seen {
+		if other, ok := s.byKey[key]; ok && other.ID != id {
+			return fmt.Errorf("%w: %q is already used by tag %q", ErrInvalidTag, key, other.Name)
+		}
+	}
+	return nil
+}
+
+// MergeTags folds the source tags into the target: managed source tags are removed
+// and every source name becomes a synonym of the target, which is created if it
+// does not exist yet. Live jobs using any of the names are rewritten.
+func (s *TagService) MergeTags(request *TagMergeRequest) (*TagMergeResult, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if err := s.Load(); err != nil {
+		return nil, err
+	}
+
+	targetKey := tagKey(request.Target)
+	if targetKey == "" {
+		return nil, fmt.Errorf("%w: target is required", ErrInvalidTag)
+	}
+	s.mu.RLock()
+	target := s.byKey[targetKey]
+	var absorbed []*models.Tag
+	keys := map[string]bool{}
+	for _, source := range request.Sources {
+		key := tagKey(source)
+		if key == "" {
+			continue
+		}
+		keys[key] = true
+		if tag, ok := s.byKey[key]; ok && (target == nil || tag.ID != target.ID) {
+			absorbed = append(absorbed, tag)
+		}
+	}
+	s.mu.RUnlock()
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: at least one source tag is required", ErrInvalidTag)
+	}
+
+	if target == nil {
+		target = &models.Tag{Name: strings.Join(strings.Fields(request.Target), " "), Key: targetKey}
+	}
+	synonyms := map[string]bool{}
+	for _, synonym := range target.Synonyms {
+		synonyms[synonym] = true
+	}
+	var absorbedIDs []primitive.ObjectID
+	for _, tag := range absorbed {
+		absorbedIDs = append(absorbedIDs, tag.ID)
+		keys[tag.Key] = true
+		for _, synonym := range tag.Synonyms {
+			keys[synonym] = true
+		}
+		if target.Category == "" {
+			target.Category = tag.Category
+		}
+		target.Usage += tag.Usage
+	}
+	for key := range keys {
+		synonyms[key] = true
+	}
+	delete(synonyms, target.Key)
+	target.Synonyms = make([]string, 0, len(synonyms))
+	for synonym := range synonyms {
+		target.Synonyms = append(target.Synonyms, synonym)
+	}
+	sort.Strings(target.Synonyms)
+	if request.Category != "" {
+		target.Category = strings.TrimSpace(request.Category)
+	}
+
+	if len(absorbedIDs) > 0 {
+		if _, err := s.tagCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": absorbedIDs}}); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	if target.ID.IsZero() {
+		target.ID = primitive.NewObjectID()
+		target.CreatedAt = now
+	}
+	target.UpdatedAt = now
+	if _, err := s.tagCollection.ReplaceOne(ctx, bson.M{"_id": target.ID}, target, options.Replace().SetUpsert(true)); err != nil {
+		return nil, err
+	}
+	if err := s.Load(); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.retagJobs(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	return &TagMergeResult{Tag: target, JobsUpdated: updated}, nil
+}
+
+// retagJobs renormalises the tags of every live job that uses one of the keys.
+func (s *TagService) retagJobs(ctx context.Context, keys map[string]bool) (int, error) {
+	patterns := bson.A{}
+	for key := range keys {
+		patterns = append(patterns, primitive.Regex{Pattern: "^\\s*" + strings.ReplaceAll(regexp.QuoteMeta(key), " ", "\\s+") + "\\s*$", Options: "i"})
+	}
+	filter := excludeDeleted(bson.M{"tags": bson.M{"$in": patterns}})
+	cursor, err := s.jobCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"tags": 1}))
+	if err != nil {
+		return 0, err
+	}
+	var jobs []*models.Job
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return 0, err
+	}
+
+	var writes []mongo.WriteModel
+	for _, job := range jobs {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": job.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"tags": s.NormalizeTags(job.Tags)}}))
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+	result, err := s.jobCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// SuggestTags autocompletes managed tags by a prefix of their name or of one of
+// their synonyms, most used first. Both are indexed, and usage is counted
+// periodically by CountUsage rather than on every keystroke.
+func (s *TagService) SuggestTags(prefix string, limit int) ([]*TagSuggestion, error) {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	if limit <= 0 {
+		limit = DefaultTagSuggestions
+	}
+	limit = min(limit, MaxTagSuggestions)
+	prefixKey := tagKey(prefix)
+	if prefixKey == "" {
+		return []*TagSuggestion{}, nil
+	}
+
+	// Keys and synonyms are stored lowercased, so an anchored, case sensitive
+	// pattern can use their indexes.
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(prefixKey)}
+	filter := bson.M{"$or": bson.A{bson.M{"key": pattern}, bson.M{"synonyms": pattern}}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "usage", Value: -1}, {Key: "key", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := s.tagCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var tags []*models.Tag
+	if err = cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]*TagSuggestion, 0, len(tags))
+	for _, tag := range tags {
+		suggestions = append(suggestions, &TagSuggestion{Name: tag.Name, Category: tag.Category, Count: tag.Usage})
+	}
+	return suggestions, nil
+}
+
+// CountUsage stores on every tag how many live jobs use it. Jobs still tagged
+// with a synonym count towards its tag.
+func (s *TagService) CountUsage() error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 100*time.Second)
+	defer cancel()
+	cursor, err := s.jobCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: excludeDeleted(bson.M{})}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return err
+	}
+	var counts []struct {
+		Tag   string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err = cursor.All(ctx, &counts); err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	usage := map[primitive.ObjectID]int{}
+	for _, tag := range s.byKey {
+		usage[tag.ID] = 0
+	}
+	for _, row := range counts {
+		if tag, ok := s.byKey[tagKey(row.Tag)]; ok {
+			usage[tag.ID] += row.Count
+		}
+	}
+	s.mu.RUnlock()
+
+	var writes []mongo.WriteModel
+	for id, count := range usage {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"usage": count}}))
+	}
+	if len(writes) == 0 {
+		return nil
+	}
+	_, err = s.tagCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrNoTransactions = errors.New("MongoDB is running standalone, but transactions need a replica set or a sharded cluster")
+
+// CheckTransactions fails with ErrNoTransactions when the server cannot run
+// transactions, so that this shows at startup rather than on the first
+// application.
+func CheckTransactions(client *mongo.Client) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return err
+	}
+	// mongos answers with msg "isdbgrid"; replica set members name their set.
+	if hello.SetName == "" && hello.Msg != "isdbgrid" {
+		return ErrNoTransactions
+	}
+	return nil
+}
+
+// withTransaction runs fn in a transaction, retrying it on transient errors. The
+// session context must be passed to every operation that belongs to it.
+// Transactions need MongoDB running as a replica set; see CheckTransactions.
+func withTransaction(ctx context.Context, client *mongo.Client, fn func(sc mongo.SessionContext) error) error {
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
@@ -0,0 +1,35 @@
+// Package storage keeps uploaded files, such as resumes, on local disk or in an
+// S3 compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("file not found")
+	ErrInvalidKey = errors.New("invalid file key")
+)
+
+// Storage stores files under slash separated keys such as "resumes/<id>.pdf".
+type Storage interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a link that downloads the file without further
+	// authentication until ttl has passed.
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// validKey rejects keys that are empty, absolute or climb out of the store.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key || strings.HasPrefix(key, "..") {
+		return ErrInvalidKey
+	}
+	return nil
+}
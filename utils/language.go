@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// PreferredLanguages lists the languages the client asked for, most wanted first,
+// as base language codes such as "en" or "de". A lang query parameter wins over
+// the Accept-Language header.
+func PreferredLanguages(c *gin.Context) []string {
+	var tags []language.Tag
+	for _, value := range strings.Split(c.Query("lang"), ",") {
+		if tag, err := language.Parse(strings.TrimSpace(value)); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	if accepted, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language")); err == nil {
+		tags = append(tags, accepted...)
+	}
+
+	seen := map[string]bool{}
+	var languages []string
+	for _, tag := range tags {
+		base, confidence := tag.Base()
+		// "*" in Accept-Language parses as "mul", which is no language to serve.
+		if confidence == language.No || base.String() == "mul" || seen[base.String()] {
+			continue
+		}
+		seen[base.String()] = true
+		languages = append(languages, base.String())
+	}
+	return languages
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const VisitorHeader = "X-Visitor-ID"
+
+// VisitorID identifies an anonymous visitor for analytics. Clients can send a
+// stable ID in the X-Visitor-ID header; otherwise a hash of the IP address and
+// user agent is used so raw addresses are never stored.
+func VisitorID(c *gin.Context) string {
+	if id := c.GetHeader(VisitorHeader); id != "" && len(id) <= 128 {
+		return id
+	}
+	sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+	return hex.EncodeToString(sum[:16])
+}